@@ -33,15 +33,28 @@ import (
 )
 
 var (
-	cfgFile    string
-	driver     string
-	dburl      string
-	format     string
-	outdir     string
-	schematype string
-	idtemplate string
-	includes   []string
-	excludes   []string
+	cfgFile     string
+	driver      string
+	dburl       string
+	format      string
+	outdir      string
+	schematype  string
+	idtemplate  string
+	draft       string
+	openapi     bool
+	references  string
+	fixedlength bool
+	decimal     string
+	variants    []string
+	pkg         string
+	gonullable  string
+	godecimal   string
+	lockfile    string
+	namespace   string
+	tmpl        string
+	html        bool
+	includes    []string
+	excludes    []string
 )
 
 func HandleGenerate(cmd *cobra.Command, args []string) {
@@ -54,15 +67,30 @@ func HandleGenerate(cmd *cobra.Command, args []string) {
 		}
 		return
 	}
+	if openapi && len(draft) == 0 {
+		draft = "openapi-3.0"
+	}
 	req := &db2jsonschema.Request{
-		Driver:     driver,
-		DataSource: dburl,
-		Format:     format,
-		Outdir:     outdir,
-		SchemaType: schematype,
-		IdTemplate: idtemplate,
-		Includes:   includes,
-		Excludes:   excludes,
+		Driver:      driver,
+		DataSource:  dburl,
+		Format:      format,
+		Outdir:      outdir,
+		SchemaType:  schematype,
+		IdTemplate:  idtemplate,
+		Draft:       draft,
+		References:  references,
+		FixedLength: fixedlength,
+		Decimal:     decimal,
+		Variants:    variants,
+		Package:     pkg,
+		GoNullable:  gonullable,
+		GoDecimal:   godecimal,
+		LockFile:    lockfile,
+		Namespace:   namespace,
+		Template:    tmpl,
+		HTML:        html,
+		Includes:    includes,
+		Excludes:    excludes,
 	}
 	err := req.Perform()
 	if err != nil {
@@ -96,12 +124,25 @@ func init() {
 
 	// Cobra also supports local flags, which will only run
 	// when this action is called directly.
-	rootCmd.Flags().StringVar(&driver, "driver", "", "The DB Driver")
+	rootCmd.Flags().StringVar(&driver, "driver", "", "The DB Driver (sqlite3,mysql,postgres)")
 	rootCmd.Flags().StringVar(&dburl, "dburl", "", "The DB URL")
-	rootCmd.Flags().StringVar(&format, "format", "", "The output format (json,yaml)")
+	rootCmd.Flags().StringVar(&format, "format", "", "The output format (json,yaml,openapi,typescript,go,proto,avro,graphql,docs)")
 	rootCmd.Flags().StringVar(&outdir, "outdir", "", "The output directory")
 	rootCmd.Flags().StringVar(&schematype, "schematype", "", "The $schema value for the generated schemas")
 	rootCmd.Flags().StringVar(&idtemplate, "idtemplate", "", "A template string for the $id value for the generated schemas")
+	rootCmd.Flags().StringVar(&draft, "draft", "", "The JSON Schema draft (draft-04,draft-06,draft-07,2019-09,2020-12,openapi-3.0,openapi-3.1)")
+	rootCmd.Flags().BoolVar(&openapi, "openapi", false, "An alias for --draft openapi-3.0")
+	rootCmd.Flags().StringVar(&references, "references", "", "How foreign keys reference other schemas (annotate,expand)")
+	rootCmd.Flags().BoolVar(&fixedlength, "fixedlength", false, "Set minLength to maxLength for fixed length CHAR(n) columns")
+	rootCmd.Flags().StringVar(&decimal, "decimal", "", "How DECIMAL(p,s) columns are represented (number,string)")
+	rootCmd.Flags().StringSliceVarP(&variants, "variant", "", []string{}, "The schema variants (read,input,create,update)")
+	rootCmd.Flags().StringVar(&pkg, "package", "", "The package name of the generated Go and protobuf code (default is models)")
+	rootCmd.Flags().StringVar(&gonullable, "gonullable", "", "How nullable columns are represented in Go structs (pointer,sql)")
+	rootCmd.Flags().StringVar(&godecimal, "godecimal", "", "The Go type of DECIMAL(p,s) columns, e.g. github.com/shopspring/decimal.Decimal")
+	rootCmd.Flags().StringVar(&lockfile, "lockfile", "", "The file persisting protobuf field numbers (default is proto.lock.json in the output directory)")
+	rootCmd.Flags().StringVar(&namespace, "namespace", "", "A template string for the namespace of the generated Avro schemas")
+	rootCmd.Flags().StringVar(&tmpl, "template", "", "A template file or directory of templates executed against the tables")
+	rootCmd.Flags().BoolVar(&html, "html", false, "Also write the docs as static HTML pages")
 	rootCmd.Flags().StringSliceVarP(&includes, "include", "", []string{}, "The tables to include")
 	rootCmd.Flags().StringSliceVarP(&excludes, "exclude", "", []string{}, "The tables to exclude")
 }
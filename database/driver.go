@@ -4,6 +4,7 @@ import (
 	"fmt"
 
 	"github.com/tgallant/db2jsonschema/database/mysql"
+	"github.com/tgallant/db2jsonschema/database/postgres"
 	"github.com/tgallant/db2jsonschema/database/sqlite3"
 	"github.com/tgallant/db2jsonschema/internal/schema"
 )
@@ -29,6 +30,11 @@ func NewConnection(i *ConnectionInfo) (Driver, error) {
 			DataSource: i.DataSource,
 		}
 		return driver, nil
+	case "postgres":
+		driver := &postgres.Driver{
+			DataSource: i.DataSource,
+		}
+		return driver, nil
 	default:
 		return nil, fmt.Errorf("Unknown driver: %s", i.Driver)
 	}
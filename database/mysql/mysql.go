@@ -3,6 +3,9 @@ package mysql
 import (
 	"database/sql"
 	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
 
 	_ "github.com/go-sql-driver/mysql"
 	"github.com/tgallant/db2jsonschema/internal/schema"
@@ -12,75 +15,327 @@ type Driver struct {
 	DataSource string
 }
 
+// MySQLTable is a row of information_schema.TABLES.
+type MySQLTable struct {
+	Name    string
+	Comment string
+}
+
+// MySQLColumn is a row of information_schema.COLUMNS.
+type MySQLColumn struct {
+	Name                   string
+	DataType               string
+	ColumnType             string
+	CharacterMaximumLength sql.NullInt64
+	IsNullable             string
+	Default                sql.NullString
+	Extra                  string
+	Comment                string
+}
+
+// MySQLColumnType is the parsed structure of a COLUMN_TYPE such as
+// `int(10) unsigned` or `enum('a','b')`.
+type MySQLColumnType struct {
+	Name      string
+	Args      []string
+	Unsigned  bool
+	Modifiers []string
+}
+
 var (
 	typesMap = map[string]*schema.FieldType{
-		"bigint unsigned": {Name: "number", Format: ""},
-		"longtext":        {Name: "string", Format: ""},
-		"datetime(3)":     {Name: "string", Format: "date-time"},
-		"tinyint(1)":      {Name: "boolean", Format: ""},
+		"tinyint":            {Name: "integer", Format: "", Size: 8},
+		"smallint":           {Name: "integer", Format: "", Size: 16},
+		"mediumint":          {Name: "integer", Format: "", Size: 24},
+		"int":                {Name: "integer", Format: "", Size: 32},
+		"integer":            {Name: "integer", Format: "", Size: 32},
+		"bigint":             {Name: "integer", Format: "", Size: 64},
+		"decimal":            {Name: "number", Format: ""},
+		"numeric":            {Name: "number", Format: ""},
+		"float":              {Name: "number", Format: ""},
+		"double":             {Name: "number", Format: ""},
+		"real":               {Name: "number", Format: ""},
+		"bit":                {Name: "integer", Format: "", Unsigned: true},
+		"year":               {Name: "integer", Format: ""},
+		"bool":               {Name: "boolean", Format: ""},
+		"boolean":            {Name: "boolean", Format: ""},
+		"char":               {Name: "string", Format: ""},
+		"varchar":            {Name: "string", Format: ""},
+		"tinytext":           {Name: "string", Format: ""},
+		"text":               {Name: "string", Format: ""},
+		"mediumtext":         {Name: "string", Format: ""},
+		"longtext":           {Name: "string", Format: ""},
+		"binary":             {Name: "string", Format: "", ContentEncoding: "base64"},
+		"varbinary":          {Name: "string", Format: "", ContentEncoding: "base64"},
+		"tinyblob":           {Name: "string", Format: "", ContentEncoding: "base64"},
+		"blob":               {Name: "string", Format: "", ContentEncoding: "base64"},
+		"mediumblob":         {Name: "string", Format: "", ContentEncoding: "base64"},
+		"longblob":           {Name: "string", Format: "", ContentEncoding: "base64"},
+		"date":               {Name: "string", Format: "date"},
+		"datetime":           {Name: "string", Format: "date-time"},
+		"timestamp":          {Name: "string", Format: "date-time"},
+		"time":               {Name: "string", Format: ""},
+		"json":               {Name: "", Format: ""},
+		"enum":               {Name: "string", Format: ""},
+		"set":                {Name: "string", Format: ""},
+		"geometry":           {Name: "string", Format: ""},
+		"point":              {Name: "string", Format: ""},
+		"linestring":         {Name: "string", Format: ""},
+		"polygon":            {Name: "string", Format: ""},
+		"multipoint":         {Name: "string", Format: ""},
+		"multilinestring":    {Name: "string", Format: ""},
+		"multipolygon":       {Name: "string", Format: ""},
+		"geometrycollection": {Name: "string", Format: ""},
+		"geomcollection":     {Name: "string", Format: ""},
+		"uuid":               {Name: "string", Format: "uuid"},
+		"inet4":              {Name: "string", Format: "ipv4"},
+		"inet6":              {Name: "string", Format: "ipv6"},
 	}
+
+	// generatedRegexp matches the EXTRA of generated columns and of columns
+	// updated by the database, but not DEFAULT_GENERATED.
+	generatedRegexp  = regexp.MustCompile(`\b(?:virtual|stored) generated\b|\bon update\b`)
+	bitLiteralRegexp = regexp.MustCompile(`^b'([01]*)'$`)
+	columnTypeRegexp = regexp.MustCompile(`^\s*([a-z0-9 ]+?)\s*(?:\((.*)\))?((?:\s+[a-z]+)*)\s*$`)
+	argRegexp        = regexp.MustCompile(`'((?:[^']|'')*)'|[^,\s]+`)
+
+	// expressionRegexp matches unquoted defaults that are expressions: a
+	// function call such as uuid() or nextval(seq), or CURRENT_TIMESTAMP.
+	expressionRegexp = regexp.MustCompile(`(?i)^(?:current_timestamp|[a-z_][\w.]*\(.*\))$`)
 )
 
-func MapMySQLType(t string) (*schema.FieldType, error) {
-	schemaType, exists := typesMap[t]
+// ParseColumnType splits a COLUMN_TYPE into its name, arguments and
+// modifiers. Quoted arguments, as used by enum and set, are unquoted.
+func ParseColumnType(t string) (*MySQLColumnType, error) {
+	matches := columnTypeRegexp.FindStringSubmatch(strings.ToLower(t))
+	if matches == nil {
+		return &MySQLColumnType{}, fmt.Errorf("Unknown data type: %s", t)
+	}
+	columnType := &MySQLColumnType{
+		Name:      matches[1],
+		Modifiers: strings.Fields(matches[3]),
+	}
+	if len(matches[2]) > 0 {
+		// Match against the original string so enum values keep their case.
+		start := strings.Index(t, "(")
+		end := strings.LastIndex(t, ")")
+		for _, arg := range argRegexp.FindAllStringSubmatch(t[start+1:end], -1) {
+			if strings.HasPrefix(arg[0], "'") {
+				columnType.Args = append(columnType.Args, strings.ReplaceAll(arg[1], "''", "'"))
+				continue
+			}
+			columnType.Args = append(columnType.Args, arg[0])
+		}
+	}
+	for _, modifier := range columnType.Modifiers {
+		if modifier == "unsigned" {
+			columnType.Unsigned = true
+		}
+	}
+	return columnType, nil
+}
+
+// MapMySQLType maps a DATA_TYPE and COLUMN_TYPE pair onto a
+// schema.FieldType. The DATA_TYPE selects the type family while the parsed
+// COLUMN_TYPE refines it, e.g. tinyint(1) and bit(1) become booleans.
+func MapMySQLType(dataType string, columnType string) (*schema.FieldType, error) {
+	parsed, err := ParseColumnType(columnType)
+	if err != nil {
+		return &schema.FieldType{}, err
+	}
+	family := strings.ToLower(dataType)
+	if len(family) == 0 {
+		family = parsed.Name
+	}
+	schemaType, exists := typesMap[family]
 	if !exists {
-		return &schema.FieldType{}, fmt.Errorf("Unknown data type: %s", t)
+		return &schema.FieldType{}, fmt.Errorf("Unknown data type: %s", columnType)
+	}
+	fieldType := *schemaType
+	isFlag := len(parsed.Args) == 1 && parsed.Args[0] == "1"
+	if family == "bit" && len(parsed.Args) == 0 {
+		// bit is shorthand for bit(1).
+		isFlag = true
+	}
+	if (family == "tinyint" || family == "bit") && isFlag {
+		return &schema.FieldType{Name: "boolean", Format: ""}, nil
+	}
+	if (fieldType.Name == "integer" || fieldType.Name == "number") && parsed.Unsigned {
+		fieldType.Unsigned = true
 	}
-	return schemaType, nil
+	if (family == "decimal" || family == "numeric") && len(parsed.Args) > 0 {
+		precision, err := strconv.Atoi(parsed.Args[0])
+		if err != nil {
+			return &schema.FieldType{}, err
+		}
+		fieldType.Precision = precision
+		if len(parsed.Args) > 1 {
+			fieldType.Scale, err = strconv.Atoi(parsed.Args[1])
+			if err != nil {
+				return &schema.FieldType{}, err
+			}
+		}
+	}
+	if family == "bit" {
+		size, err := strconv.Atoi(parsed.Args[0])
+		if err != nil {
+			return &schema.FieldType{}, err
+		}
+		fieldType.Size = size
+	}
+	if family == "enum" || family == "set" {
+		for _, value := range parsed.Args {
+			fieldType.Enum = append(fieldType.Enum, value)
+		}
+	}
+	if family == "set" {
+		// A set holds any combination of its members, each at most once.
+		return &schema.FieldType{Name: "array", Items: &fieldType, UniqueItems: true}, nil
+	}
+	return &fieldType, nil
 }
 
-func SelectTables(conn *sql.DB) ([]string, error) {
-	row, err := conn.Query(`show tables`)
+// ParseDefault converts the COLUMN_DEFAULT of a column into the JSON value
+// of the default. MySQL reports literals unquoted and marks expressions
+// with DEFAULT_GENERATED, while MariaDB quotes string literals, reports
+// NULL as the string NULL and leaves expressions unmarked. Unquoted
+// defaults that are function calls or CURRENT_TIMESTAMP, or that are not
+// literals of the column type, are returned as an expression.
+func ParseDefault(t *schema.FieldType, column *MySQLColumn) (interface{}, string) {
+	if !column.Default.Valid {
+		return nil, ""
+	}
+	literal := column.Default.String
+	if strings.Contains(strings.ToLower(column.Extra), "default_generated") {
+		return nil, literal
+	}
+	switch {
+	case literal == "NULL":
+		return nil, ""
+	case len(literal) > 1 && strings.HasPrefix(literal, "'") && strings.HasSuffix(literal, "'"):
+		literal = strings.ReplaceAll(literal[1:len(literal)-1], "''", "'")
+	case bitLiteralRegexp.MatchString(literal):
+		bits := bitLiteralRegexp.FindStringSubmatch(literal)[1]
+		value, err := strconv.ParseUint("0"+bits, 2, 64)
+		if err != nil {
+			return nil, literal
+		}
+		literal = strconv.FormatUint(value, 10)
+	case expressionRegexp.MatchString(literal):
+		return nil, literal
+	}
+	if t.Name == "array" && t.Items != nil {
+		// set defaults list their members separated by commas.
+		var values = []interface{}{}
+		for _, member := range strings.Split(literal, ",") {
+			if len(member) > 0 {
+				values = append(values, member)
+			}
+		}
+		return values, ""
+	}
+	value, ok := schema.ConvertLiteral(t, literal)
+	if !ok {
+		return nil, literal
+	}
+	return value, ""
+}
+
+// SelectTables returns the tables and views of the current database. The
+// TABLE_COMMENT of a view is always VIEW, so views have no comment.
+func SelectTables(conn *sql.DB) ([]*MySQLTable, error) {
+	row, err := conn.Query(`
+select TABLE_NAME, TABLE_TYPE, TABLE_COMMENT
+from information_schema.TABLES
+where TABLE_SCHEMA = database()
+order by TABLE_NAME`)
 	if err != nil {
 		return nil, err
 	}
 	defer row.Close()
-	var tables []string
+	var tables []*MySQLTable
 	for row.Next() {
-		var table string
-		err = row.Scan(&table)
+		table := &MySQLTable{}
+		var tableType string
+		var comment sql.NullString
+		err = row.Scan(&table.Name, &tableType, &comment)
 		if err != nil {
 			return nil, err
 		}
+		if tableType != "VIEW" {
+			table.Comment = comment.String
+		}
 		tables = append(tables, table)
 	}
 	return tables, nil
 }
 
-func DescribeTable(conn *sql.DB, tableName string) (*schema.Table, error) {
-	query := fmt.Sprintf("describe %s", tableName)
-	row, err := conn.Query(query)
+func SelectColumns(conn *sql.DB, tableName string) ([]*MySQLColumn, error) {
+	row, err := conn.Query(`
+select
+  COLUMN_NAME,
+  DATA_TYPE,
+  COLUMN_TYPE,
+  CHARACTER_MAXIMUM_LENGTH,
+  IS_NULLABLE,
+  COLUMN_DEFAULT,
+  EXTRA,
+  COLUMN_COMMENT
+from information_schema.COLUMNS
+where TABLE_SCHEMA = database() and TABLE_NAME = ?
+order by ORDINAL_POSITION`, tableName)
 	if err != nil {
 		return nil, err
 	}
 	defer row.Close()
-	var fields []*schema.Field
+	var columns []*MySQLColumn
 	for row.Next() {
-		var name string
-		var datatype string
-		var nullable sql.NullString
-		var key sql.NullString
-		var defaultValue sql.NullString
-		var extra sql.NullString
+		column := &MySQLColumn{}
 		err := row.Scan(
-			&name,
-			&datatype,
-			&nullable,
-			&key,
-			&defaultValue,
-			&extra,
+			&column.Name,
+			&column.DataType,
+			&column.ColumnType,
+			&column.CharacterMaximumLength,
+			&column.IsNullable,
+			&column.Default,
+			&column.Extra,
+			&column.Comment,
 		)
 		if err != nil {
 			return nil, err
 		}
-		fieldType, err := MapMySQLType(datatype)
+		columns = append(columns, column)
+	}
+	return columns, nil
+}
+
+func DescribeTable(conn *sql.DB, tableName string) (*schema.Table, error) {
+	columns, err := SelectColumns(conn, tableName)
+	if err != nil {
+		return nil, err
+	}
+	var fields []*schema.Field
+	for _, column := range columns {
+		fieldType, err := MapMySQLType(column.DataType, column.ColumnType)
 		if err != nil {
 			return nil, err
 		}
+		dataType := strings.ToLower(column.DataType)
+		extra := strings.ToLower(column.Extra)
+		if (dataType == "char" || dataType == "varchar") && column.CharacterMaximumLength.Valid {
+			fieldType.MaxLength = int(column.CharacterMaximumLength.Int64)
+			fieldType.FixedLength = dataType == "char"
+		}
 		field := &schema.Field{
-			Name: name,
-			Type: fieldType,
+			Name:          column.Name,
+			Type:          fieldType,
+			SQLType:       column.ColumnType,
+			Nullable:      column.IsNullable == "YES",
+			Comment:       column.Comment,
+			AutoIncrement: strings.Contains(extra, "auto_increment"),
+			Generated:     generatedRegexp.MatchString(extra),
 		}
+		field.Default, field.DefaultExpression = ParseDefault(fieldType, column)
 		fields = append(fields, field)
 	}
 	table := &schema.Table{
@@ -90,6 +345,77 @@ func DescribeTable(conn *sql.DB, tableName string) (*schema.Table, error) {
 	return table, nil
 }
 
+func SelectForeignKeys(conn *sql.DB, tableName string) ([]*schema.ForeignKey, error) {
+	row, err := conn.Query(`
+select COLUMN_NAME, REFERENCED_TABLE_NAME, REFERENCED_COLUMN_NAME
+from information_schema.KEY_COLUMN_USAGE
+where TABLE_SCHEMA = database() and TABLE_NAME = ? and REFERENCED_TABLE_NAME is not null
+order by CONSTRAINT_NAME, ORDINAL_POSITION`, tableName)
+	if err != nil {
+		return nil, err
+	}
+	defer row.Close()
+	var foreignKeys []*schema.ForeignKey
+	for row.Next() {
+		foreignKey := &schema.ForeignKey{}
+		err = row.Scan(
+			&foreignKey.Field,
+			&foreignKey.ReferencedTable,
+			&foreignKey.ReferencedField,
+		)
+		if err != nil {
+			return nil, err
+		}
+		foreignKeys = append(foreignKeys, foreignKey)
+	}
+	return foreignKeys, nil
+}
+
+// SelectKeys returns the columns of the primary key and of every unique
+// index of a table. Functional key parts have no column and cause the
+// index to be skipped.
+func SelectKeys(conn *sql.DB, tableName string) ([]string, [][]string, error) {
+	row, err := conn.Query(`
+select INDEX_NAME, COLUMN_NAME
+from information_schema.STATISTICS
+where TABLE_SCHEMA = database() and TABLE_NAME = ? and NON_UNIQUE = 0
+order by INDEX_NAME = 'PRIMARY' desc, INDEX_NAME, SEQ_IN_INDEX`, tableName)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer row.Close()
+	var primaryKeys []string
+	var uniqueKeys [][]string
+	var indexes []string
+	var columns = make(map[string][]string)
+	var functional = make(map[string]bool)
+	for row.Next() {
+		var index string
+		var column sql.NullString
+		err = row.Scan(&index, &column)
+		if err != nil {
+			return nil, nil, err
+		}
+		if _, exists := columns[index]; !exists {
+			indexes = append(indexes, index)
+		}
+		columns[index] = append(columns[index], column.String)
+		if !column.Valid {
+			functional[index] = true
+		}
+	}
+	for _, index := range indexes {
+		if index == "PRIMARY" {
+			primaryKeys = columns[index]
+			continue
+		}
+		if !functional[index] {
+			uniqueKeys = append(uniqueKeys, columns[index])
+		}
+	}
+	return primaryKeys, uniqueKeys, nil
+}
+
 func (d *Driver) ReadTables() ([]*schema.Table, error) {
 	conn, err := sql.Open("mysql", d.DataSource)
 	if err != nil {
@@ -102,7 +428,16 @@ func (d *Driver) ReadTables() ([]*schema.Table, error) {
 	}
 	var parsedTables []*schema.Table
 	for _, table := range tables {
-		parsedTable, err := DescribeTable(conn, table)
+		parsedTable, err := DescribeTable(conn, table.Name)
+		if err != nil {
+			return nil, err
+		}
+		parsedTable.Comment = table.Comment
+		parsedTable.ForeignKeys, err = SelectForeignKeys(conn, table.Name)
+		if err != nil {
+			return nil, err
+		}
+		parsedTable.PrimaryKeys, parsedTable.UniqueKeys, err = SelectKeys(conn, table.Name)
 		if err != nil {
 			return nil, err
 		}
@@ -0,0 +1,248 @@
+package mysql
+
+import (
+	"database/sql"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseColumnType(t *testing.T) {
+	columnType, err := ParseColumnType("int(10) unsigned zerofill")
+	assert.Nil(t, err, "parsing the column type should succeed")
+	assert.Equal(t, "int", columnType.Name, "the name should be `int`")
+	assert.Equal(t, []string{"10"}, columnType.Args, "the args should be parsed")
+	assert.True(t, columnType.Unsigned, "the column should be unsigned")
+	assert.Equal(t, []string{"unsigned", "zerofill"}, columnType.Modifiers, "the modifiers should be parsed")
+}
+
+func TestParseColumnTypeEnum(t *testing.T) {
+	columnType, err := ParseColumnType("enum('Small','it''s, big')")
+	assert.Nil(t, err, "parsing the column type should succeed")
+	assert.Equal(t, "enum", columnType.Name, "the name should be `enum`")
+	assert.Equal(t, []string{"Small", "it's, big"}, columnType.Args, "the values should be unquoted")
+}
+
+func TestMapMySQLType(t *testing.T) {
+	cases := []struct {
+		dataType   string
+		columnType string
+		name       string
+		format     string
+	}{
+		{"bigint", "bigint unsigned", "integer", ""},
+		{"int", "int(11)", "integer", ""},
+		{"tinyint", "tinyint(1)", "boolean", ""},
+		{"tinyint", "tinyint(4)", "integer", ""},
+		{"decimal", "decimal(10,2)", "number", ""},
+		{"double", "double", "number", ""},
+		{"varchar", "varchar(191)", "string", ""},
+		{"longtext", "longtext", "string", ""},
+		{"blob", "blob", "string", ""},
+		{"datetime", "datetime(3)", "string", "date-time"},
+		{"timestamp", "timestamp", "string", "date-time"},
+		{"date", "date", "string", "date"},
+		{"year", "year", "integer", ""},
+		{"json", "json", "", ""},
+		{"enum", "enum('a','b')", "string", ""},
+		{"set", "set('a','b')", "array", ""},
+		{"bit", "bit(1)", "boolean", ""},
+		{"bit", "bit(8)", "integer", ""},
+		{"point", "point", "string", ""},
+	}
+	for _, c := range cases {
+		fieldType, err := MapMySQLType(c.dataType, c.columnType)
+		assert.Nilf(t, err, "mapping %s should succeed", c.columnType)
+		assert.Equalf(t, c.name, fieldType.Name, "the type of %s should be `%s`", c.columnType, c.name)
+		assert.Equalf(t, c.format, fieldType.Format, "the format of %s should be `%s`", c.columnType, c.format)
+	}
+}
+
+func TestMapMySQLTypeBinary(t *testing.T) {
+	for _, dataType := range []string{"binary", "varbinary", "tinyblob", "blob", "mediumblob", "longblob"} {
+		fieldType, err := MapMySQLType(dataType, dataType)
+		assert.Nilf(t, err, "mapping %s should succeed", dataType)
+		assert.Equalf(t, "base64", fieldType.ContentEncoding, "%s should be base64 encoded", dataType)
+	}
+}
+
+func TestMapMySQLTypeIntegerSize(t *testing.T) {
+	cases := []struct {
+		dataType   string
+		columnType string
+		size       int
+		unsigned   bool
+	}{
+		{"tinyint", "tinyint(4)", 8, false},
+		{"smallint", "smallint unsigned", 16, true},
+		{"mediumint", "mediumint", 24, false},
+		{"int", "int(10) unsigned", 32, true},
+		{"bigint", "bigint", 64, false},
+		{"bit", "bit(12)", 12, true},
+	}
+	for _, c := range cases {
+		fieldType, err := MapMySQLType(c.dataType, c.columnType)
+		assert.Nilf(t, err, "mapping %s should succeed", c.columnType)
+		assert.Equalf(t, c.size, fieldType.Size, "the size of %s should be %d", c.columnType, c.size)
+		assert.Equalf(t, c.unsigned, fieldType.Unsigned, "the signedness of %s should be parsed", c.columnType)
+	}
+}
+
+func TestMapMySQLTypeDecimal(t *testing.T) {
+	fieldType, err := MapMySQLType("decimal", "decimal(10,2) unsigned")
+	assert.Nil(t, err, "mapping a decimal should succeed")
+	assert.Equal(t, 10, fieldType.Precision, "the precision should be 10")
+	assert.Equal(t, 2, fieldType.Scale, "the scale should be 2")
+	assert.True(t, fieldType.Unsigned, "the decimal should be unsigned")
+	fieldType, err = MapMySQLType("double", "double")
+	assert.Nil(t, err, "mapping a double should succeed")
+	assert.Equal(t, 0, fieldType.Precision, "only decimals should have a precision")
+}
+
+func TestMapMySQLTypeEnum(t *testing.T) {
+	fieldType, err := MapMySQLType("enum", "enum('small','medium','large')")
+	assert.Nil(t, err, "mapping an enum should succeed")
+	assert.Equal(t, "string", fieldType.Name, "the type should be `string`")
+	assert.Equal(t, []interface{}{"small", "medium", "large"}, fieldType.Enum, "the enum values should be parsed")
+	fieldType, err = MapMySQLType("set", "set('red','green')")
+	assert.Nil(t, err, "mapping a set should succeed")
+	assert.Equal(t, "array", fieldType.Name, "the type should be `array`")
+	assert.True(t, fieldType.UniqueItems, "the items should be unique")
+	assert.Equal(t, "string", fieldType.Items.Name, "the item type should be `string`")
+	assert.Equal(t, []interface{}{"red", "green"}, fieldType.Items.Enum, "the set values should be parsed")
+}
+
+func TestMapMySQLTypeUnknown(t *testing.T) {
+	_, err := MapMySQLType("vector", "vector(3)")
+	assert.NotNil(t, err, "mapping an unknown type should fail")
+}
+
+func TestParseDefault(t *testing.T) {
+	cases := []struct {
+		dataType   string
+		columnType string
+		dflt       string
+		extra      string
+		value      interface{}
+		expression string
+	}{
+		{"varchar", "varchar(10)", "abc", "", "abc", ""},
+		{"varchar", "varchar(10)", "'it''s'", "", "it's", ""},
+		{"varchar", "varchar(10)", "n/a (none)", "", "n/a (none)", ""},
+		{"varchar", "varchar(10)", "uuid()", "DEFAULT_GENERATED", nil, "uuid()"},
+		{"varchar", "varchar(36)", "uuid()", "", nil, "uuid()"},
+		{"varchar", "varchar(36)", "'uuid()'", "", "uuid()", ""},
+		{"bigint", "bigint(20)", "nextval(`db`.`seq`)", "", nil, "nextval(`db`.`seq`)"},
+		{"varchar", "varchar(10)", "NULL", "", nil, ""},
+		{"int", "int(11)", "42", "", int64(42), ""},
+		{"decimal", "decimal(10,2)", "9.99", "", 9.99, ""},
+		{"bit", "bit(1)", "b'1'", "", true, ""},
+		{"set", "set('a','b')", "a,b", "", []interface{}{"a", "b"}, ""},
+		{"datetime", "datetime", "CURRENT_TIMESTAMP", "DEFAULT_GENERATED", nil, "CURRENT_TIMESTAMP"},
+		{"timestamp", "timestamp", "current_timestamp()", "", nil, "current_timestamp()"},
+		{"int", "int(11)", "abc", "", nil, "abc"},
+	}
+	for _, c := range cases {
+		fieldType, err := MapMySQLType(c.dataType, c.columnType)
+		assert.Nilf(t, err, "mapping %s should succeed", c.columnType)
+		column := &MySQLColumn{
+			Default: sql.NullString{String: c.dflt, Valid: true},
+			Extra:   c.extra,
+		}
+		value, expression := ParseDefault(fieldType, column)
+		assert.Equalf(t, c.value, value, "the default value of %s should be parsed", c.dflt)
+		assert.Equalf(t, c.expression, expression, "the default expression of %s should be kept", c.dflt)
+	}
+}
+
+func TestSelectTables(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.Nil(t, err, "creating the mock connection should succeed")
+	defer db.Close()
+	rows := sqlmock.NewRows([]string{"TABLE_NAME", "TABLE_TYPE", "TABLE_COMMENT"}).
+		AddRow("albums", "BASE TABLE", "Released albums").
+		AddRow("album_titles", "VIEW", "VIEW")
+	mock.ExpectQuery("from information_schema.TABLES").
+		WillReturnRows(rows)
+	tables, err := SelectTables(db)
+	assert.Nil(t, err, "selecting the tables should succeed")
+	assert.Equal(t, 2, len(tables), "views should be selected")
+	assert.Equal(t, "Released albums", tables[0].Comment, "the table comment should be read")
+	assert.Empty(t, tables[1].Comment, "views should not have a comment")
+	assert.Nil(t, mock.ExpectationsWereMet(), "all queries should be executed")
+}
+
+func TestDescribeTable(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.Nil(t, err, "creating the mock connection should succeed")
+	defer db.Close()
+	columns := []string{
+		"COLUMN_NAME",
+		"DATA_TYPE",
+		"COLUMN_TYPE",
+		"CHARACTER_MAXIMUM_LENGTH",
+		"IS_NULLABLE",
+		"COLUMN_DEFAULT",
+		"EXTRA",
+		"COLUMN_COMMENT",
+	}
+	rows := sqlmock.NewRows(columns).
+		AddRow("id", "bigint", "bigint unsigned", nil, "NO", nil, "auto_increment", "").
+		AddRow("title", "varchar", "varchar(255)", 255, "YES", nil, "", "The album title").
+		AddRow("released", "tinyint", "tinyint(1)", nil, "YES", "0", "", "").
+		AddRow("code", "char", "char(3)", 3, "NO", nil, "", "").
+		AddRow("total", "int", "int(11)", nil, "YES", nil, "VIRTUAL GENERATED", "").
+		AddRow("created_at", "datetime", "datetime", nil, "YES", "CURRENT_TIMESTAMP", "DEFAULT_GENERATED", "").
+		AddRow("updated_at", "datetime", "datetime", nil, "YES", "CURRENT_TIMESTAMP", "DEFAULT_GENERATED on update CURRENT_TIMESTAMP", "").
+		AddRow("uuid", "varchar", "varchar(36)", 36, "NO", "uuid()", "", "").
+		AddRow("token", "varchar", "varchar(36)", 36, "NO", "'uuid()'", "", "")
+	mock.ExpectQuery("from information_schema.COLUMNS").
+		WithArgs("albums").
+		WillReturnRows(rows)
+	table, err := DescribeTable(db, "albums")
+	assert.Nil(t, err, "describing the table should succeed")
+	assert.Equal(t, "albums", table.Name, "the table name should be `albums`")
+	assert.Equal(t, 9, len(table.Fields), "the table should have 9 fields")
+	assert.Equal(t, "integer", table.Fields[0].Type.Name, "the field type should be `integer`")
+	assert.False(t, table.Fields[0].Nullable, "the field should not be nullable")
+	assert.Equal(t, "string", table.Fields[1].Type.Name, "the field type should be `string`")
+	assert.True(t, table.Fields[1].Nullable, "the field should be nullable")
+	assert.Equal(t, "The album title", table.Fields[1].Comment, "the column comment should be read")
+	assert.Equal(t, "boolean", table.Fields[2].Type.Name, "the field type should be `boolean`")
+	assert.True(t, table.Fields[0].AutoIncrement, "the field should be auto-increment")
+	assert.False(t, table.Fields[1].AutoIncrement, "the field should not be auto-increment")
+	assert.True(t, table.Fields[4].Generated, "generated columns should be generated")
+	assert.False(t, table.Fields[5].Generated, "columns with a default expression should not be generated")
+	assert.True(t, table.Fields[6].Generated, "columns updated by the database should be generated")
+	assert.Equal(t, false, table.Fields[2].Default, "the default should be converted to a boolean")
+	assert.Equal(t, 255, table.Fields[1].Type.MaxLength, "the max length should be 255")
+	assert.False(t, table.Fields[1].Type.FixedLength, "varchar should not have a fixed length")
+	assert.Equal(t, 3, table.Fields[3].Type.MaxLength, "the max length should be 3")
+	assert.True(t, table.Fields[3].Type.FixedLength, "char should have a fixed length")
+	assert.Equal(t, "bigint unsigned", table.Fields[0].SQLType, "the SQL type should be the column type")
+	assert.Equal(t, "uuid()", table.Fields[7].DefaultExpression, "unquoted MariaDB expressions should be expressions")
+	assert.Nil(t, table.Fields[7].Default, "unquoted MariaDB expressions should not be values")
+	assert.Equal(t, "uuid()", table.Fields[8].Default, "quoted MariaDB literals should be values")
+	assert.Empty(t, table.Fields[8].DefaultExpression, "quoted MariaDB literals should not be expressions")
+	assert.Nil(t, mock.ExpectationsWereMet(), "all queries should be executed")
+}
+
+func TestSelectKeys(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.Nil(t, err, "creating the mock connection should succeed")
+	defer db.Close()
+	rows := sqlmock.NewRows([]string{"INDEX_NAME", "COLUMN_NAME"}).
+		AddRow("PRIMARY", "album_id").
+		AddRow("PRIMARY", "position").
+		AddRow("functional", nil).
+		AddRow("isrc", "isrc")
+	mock.ExpectQuery("from information_schema.STATISTICS").
+		WithArgs("tracks").
+		WillReturnRows(rows)
+	primaryKeys, uniqueKeys, err := SelectKeys(db, "tracks")
+	assert.Nil(t, err, "selecting the keys should succeed")
+	assert.Equal(t, []string{"album_id", "position"}, primaryKeys, "the primary key should be composite")
+	assert.Equal(t, [][]string{{"isrc"}}, uniqueKeys, "functional indexes should be skipped")
+	assert.Nil(t, mock.ExpectationsWereMet(), "all queries should be executed")
+}
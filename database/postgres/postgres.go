@@ -0,0 +1,374 @@
+package postgres
+
+import (
+	"database/sql"
+	"fmt"
+	"regexp"
+	"strings"
+
+	_ "github.com/lib/pq"
+	log "github.com/sirupsen/logrus"
+	"github.com/tgallant/db2jsonschema/internal/schema"
+)
+
+type Driver struct {
+	DataSource string
+}
+
+var (
+	typesMap = map[string]*schema.FieldType{
+		"int2":        {Name: "integer", Format: "", Size: 16},
+		"int4":        {Name: "integer", Format: "", Size: 32},
+		"int8":        {Name: "integer", Format: "", Size: 64},
+		"oid":         {Name: "integer", Format: "", Size: 32, Unsigned: true},
+		"numeric":     {Name: "number", Format: ""},
+		"float4":      {Name: "number", Format: ""},
+		"float8":      {Name: "number", Format: ""},
+		"money":       {Name: "string", Format: ""},
+		"text":        {Name: "string", Format: ""},
+		"varchar":     {Name: "string", Format: ""},
+		"bpchar":      {Name: "string", Format: ""},
+		"char":        {Name: "string", Format: ""},
+		"name":        {Name: "string", Format: ""},
+		"citext":      {Name: "string", Format: ""},
+		"bool":        {Name: "boolean", Format: ""},
+		"timestamp":   {Name: "string", Format: "date-time"},
+		"timestamptz": {Name: "string", Format: "date-time"},
+		"date":        {Name: "string", Format: "date"},
+		"time":        {Name: "string", Format: "time"},
+		"timetz":      {Name: "string", Format: "time"},
+		"interval":    {Name: "string", Format: ""},
+		"uuid":        {Name: "string", Format: "uuid"},
+		"inet":        {Name: "string", Format: ""},
+		"cidr":        {Name: "string", Format: ""},
+		"macaddr":     {Name: "string", Format: ""},
+		"bytea":       {Name: "string", Format: "", ContentEncoding: "base64"},
+		"xml":         {Name: "string", Format: ""},
+		"json":        {Name: "", Format: ""},
+		"jsonb":       {Name: "", Format: ""},
+	}
+
+	// defaultLiteralRegexp matches a constant column_default, which may be
+	// parenthesized and followed by type casts, e.g. 'a'::character varying.
+	defaultLiteralRegexp = regexp.MustCompile(`^\(?('(?:[^']|'')*'|[-+]?(?:\d+\.?\d*|\.\d+)(?:[eE][-+]?\d+)?|true|false|NULL)\)?(?:::[\w\s."]+(?:\[\])*)*$`)
+)
+
+// MapPostgresType maps a udt_name from information_schema.columns onto a
+// schema.FieldType. Array types are reported with a leading underscore
+// (e.g. _int4) and map onto an array of their element type. User-defined
+// enum types are looked up in enums.
+func MapPostgresType(t string, enums map[string][]interface{}) (*schema.FieldType, error) {
+	if strings.HasPrefix(t, "_") {
+		itemType, err := MapPostgresType(strings.TrimPrefix(t, "_"), enums)
+		if err != nil {
+			return &schema.FieldType{}, err
+		}
+		return &schema.FieldType{Name: "array", Items: itemType}, nil
+	}
+	if labels, exists := enums[t]; exists {
+		return &schema.FieldType{Name: "string", Format: "", Enum: labels}, nil
+	}
+	schemaType, exists := typesMap[t]
+	if !exists {
+		return &schema.FieldType{}, fmt.Errorf("Unknown data type: %s", t)
+	}
+	fieldType := *schemaType
+	return &fieldType, nil
+}
+
+// SelectEnums returns the labels of every enum type in their declared
+// order, keyed by the schema of the type and then its name. Columns may use
+// enum types of other schemas than their table.
+func SelectEnums(conn *sql.DB) (map[string]map[string][]interface{}, error) {
+	row, err := conn.Query(`
+select n.nspname, t.typname, e.enumlabel
+from pg_catalog.pg_enum e
+join pg_catalog.pg_type t on t.oid = e.enumtypid
+join pg_catalog.pg_namespace n on n.oid = t.typnamespace
+order by n.nspname, t.typname, e.enumsortorder`)
+	if err != nil {
+		return nil, err
+	}
+	defer row.Close()
+	var enums = make(map[string]map[string][]interface{})
+	for row.Next() {
+		var namespace string
+		var name string
+		var label string
+		err = row.Scan(&namespace, &name, &label)
+		if err != nil {
+			return nil, err
+		}
+		if enums[namespace] == nil {
+			enums[namespace] = make(map[string][]interface{})
+		}
+		enums[namespace][name] = append(enums[namespace][name], label)
+	}
+	return enums, nil
+}
+
+// ParseDefault converts the column_default of a column into the JSON value
+// of the default. Defaults that are not constants, such as now() or
+// nextval('albums_id_seq'::regclass), are returned as an expression instead.
+func ParseDefault(t *schema.FieldType, columnDefault sql.NullString) (interface{}, string) {
+	if !columnDefault.Valid {
+		return nil, ""
+	}
+	matches := defaultLiteralRegexp.FindStringSubmatch(columnDefault.String)
+	if matches == nil {
+		return nil, columnDefault.String
+	}
+	literal := matches[1]
+	if literal == "NULL" {
+		return nil, ""
+	}
+	if strings.HasPrefix(literal, "'") {
+		literal = strings.ReplaceAll(literal[1:len(literal)-1], "''", "'")
+	}
+	value, ok := schema.ConvertLiteral(t, literal)
+	if !ok {
+		return nil, columnDefault.String
+	}
+	return value, ""
+}
+
+// PostgresTable is a table of the current schema with its COMMENT ON.
+type PostgresTable struct {
+	Name    string
+	Comment string
+}
+
+func SelectTables(conn *sql.DB) ([]*PostgresTable, error) {
+	row, err := conn.Query(`
+select c.relname, coalesce(obj_description(c.oid, 'pg_class'), '')
+from pg_catalog.pg_class c
+join pg_catalog.pg_namespace n on n.oid = c.relnamespace
+where n.nspname = current_schema() and c.relkind in ('r', 'p')
+order by c.relname`)
+	if err != nil {
+		return nil, err
+	}
+	defer row.Close()
+	var tables []*PostgresTable
+	for row.Next() {
+		table := &PostgresTable{}
+		err = row.Scan(&table.Name, &table.Comment)
+		if err != nil {
+			return nil, err
+		}
+		tables = append(tables, table)
+	}
+	return tables, nil
+}
+
+// FormatSQLType returns the declared type of a column from its udt_name
+// and type modifiers, e.g. varchar(255) or numeric(10,2).
+func FormatSQLType(datatype string, maxLength sql.NullInt64, precision sql.NullInt64, scale sql.NullInt64) string {
+	if maxLength.Valid {
+		return fmt.Sprintf("%s(%d)", datatype, maxLength.Int64)
+	}
+	if datatype == "numeric" && precision.Valid {
+		return fmt.Sprintf("%s(%d,%d)", datatype, precision.Int64, scale.Int64)
+	}
+	return datatype
+}
+
+func DescribeTable(conn *sql.DB, tableName string, enums map[string]map[string][]interface{}) (*schema.Table, error) {
+	row, err := conn.Query(`
+select
+  column_name,
+  udt_schema,
+  udt_name,
+  is_nullable,
+  column_default,
+  character_maximum_length,
+  numeric_precision,
+  numeric_scale,
+  is_identity,
+  is_generated,
+  coalesce(col_description(format('%I.%I', table_schema, table_name)::regclass, ordinal_position), '')
+from information_schema.columns
+where table_schema = current_schema() and table_name = $1
+order by ordinal_position`, tableName)
+	if err != nil {
+		return nil, err
+	}
+	defer row.Close()
+	var fields []*schema.Field
+	for row.Next() {
+		var name string
+		var udtSchema string
+		var datatype string
+		var nullable string
+		var columnDefault sql.NullString
+		var maxLength sql.NullInt64
+		var precision sql.NullInt64
+		var scale sql.NullInt64
+		var identity string
+		var generated string
+		var comment string
+		err := row.Scan(
+			&name,
+			&udtSchema,
+			&datatype,
+			&nullable,
+			&columnDefault,
+			&maxLength,
+			&precision,
+			&scale,
+			&identity,
+			&generated,
+			&comment,
+		)
+		if err != nil {
+			return nil, err
+		}
+		fieldType, err := MapPostgresType(datatype, enums[udtSchema])
+		if err != nil {
+			// Columns of types without a JSON equivalent, e.g. tsvector,
+			// hold any value rather than failing the whole run.
+			log.Warnf("%s, leaving %s.%s untyped", err, tableName, name)
+			fieldType = &schema.FieldType{}
+		}
+		if maxLength.Valid {
+			// Only varchar(n) and char(n) report a maximum length.
+			fieldType.MaxLength = int(maxLength.Int64)
+			fieldType.FixedLength = datatype == "bpchar"
+		}
+		// numeric without a precision can store any value, other number
+		// types report the binary precision of their storage.
+		if datatype == "numeric" && precision.Valid {
+			fieldType.Precision = int(precision.Int64)
+			fieldType.Scale = int(scale.Int64)
+		}
+		// serial columns default to the next value of their sequence.
+		isSerial := strings.HasPrefix(columnDefault.String, "nextval(")
+		field := &schema.Field{
+			Name:          name,
+			Type:          fieldType,
+			SQLType:       FormatSQLType(datatype, maxLength, precision, scale),
+			Nullable:      nullable == "YES",
+			Comment:       comment,
+			AutoIncrement: identity == "YES" || isSerial,
+			Generated:     generated == "ALWAYS",
+		}
+		field.Default, field.DefaultExpression = ParseDefault(fieldType, columnDefault)
+		fields = append(fields, field)
+	}
+	table := &schema.Table{
+		Name:   tableName,
+		Fields: fields,
+	}
+	return table, nil
+}
+
+func SelectForeignKeys(conn *sql.DB, tableName string) ([]*schema.ForeignKey, error) {
+	row, err := conn.Query(`
+select a.attname, rf.relname, af.attname
+from pg_catalog.pg_constraint c
+join pg_catalog.pg_class r on r.oid = c.conrelid
+join pg_catalog.pg_namespace n on n.oid = r.relnamespace
+join pg_catalog.pg_class rf on rf.oid = c.confrelid
+cross join lateral unnest(c.conkey, c.confkey) as k(attnum, fattnum)
+join pg_catalog.pg_attribute a on a.attrelid = c.conrelid and a.attnum = k.attnum
+join pg_catalog.pg_attribute af on af.attrelid = c.confrelid and af.attnum = k.fattnum
+where c.contype = 'f' and n.nspname = current_schema() and r.relname = $1
+order by c.conname`, tableName)
+	if err != nil {
+		return nil, err
+	}
+	defer row.Close()
+	var foreignKeys []*schema.ForeignKey
+	for row.Next() {
+		foreignKey := &schema.ForeignKey{}
+		err = row.Scan(
+			&foreignKey.Field,
+			&foreignKey.ReferencedTable,
+			&foreignKey.ReferencedField,
+		)
+		if err != nil {
+			return nil, err
+		}
+		foreignKeys = append(foreignKeys, foreignKey)
+	}
+	return foreignKeys, nil
+}
+
+// SelectKeys returns the columns of the primary key and of every unique
+// constraint of a table in key order.
+func SelectKeys(conn *sql.DB, tableName string) ([]string, [][]string, error) {
+	row, err := conn.Query(`
+select c.conname, c.contype, a.attname
+from pg_catalog.pg_constraint c
+join pg_catalog.pg_class r on r.oid = c.conrelid
+join pg_catalog.pg_namespace n on n.oid = r.relnamespace
+cross join lateral unnest(c.conkey) with ordinality as k(attnum, position)
+join pg_catalog.pg_attribute a on a.attrelid = c.conrelid and a.attnum = k.attnum
+where c.contype in ('p', 'u') and n.nspname = current_schema() and r.relname = $1
+order by c.contype, c.conname, k.position`, tableName)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer row.Close()
+	var primaryKeys []string
+	var uniqueKeys [][]string
+	var constraints []string
+	var columns = make(map[string][]string)
+	var kinds = make(map[string]string)
+	for row.Next() {
+		var constraint string
+		var kind string
+		var column string
+		err = row.Scan(&constraint, &kind, &column)
+		if err != nil {
+			return nil, nil, err
+		}
+		if _, exists := columns[constraint]; !exists {
+			constraints = append(constraints, constraint)
+		}
+		columns[constraint] = append(columns[constraint], column)
+		kinds[constraint] = kind
+	}
+	for _, constraint := range constraints {
+		if kinds[constraint] == "p" {
+			primaryKeys = columns[constraint]
+			continue
+		}
+		uniqueKeys = append(uniqueKeys, columns[constraint])
+	}
+	return primaryKeys, uniqueKeys, nil
+}
+
+func (d *Driver) ReadTables() ([]*schema.Table, error) {
+	conn, err := sql.Open("postgres", d.DataSource)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+	tables, err := SelectTables(conn)
+	if err != nil {
+		return nil, err
+	}
+	enums, err := SelectEnums(conn)
+	if err != nil {
+		return nil, err
+	}
+	var parsedTables []*schema.Table
+	for _, table := range tables {
+		parsedTable, err := DescribeTable(conn, table.Name, enums)
+		if err != nil {
+			return nil, err
+		}
+		parsedTable.Comment = table.Comment
+		parsedTable.ForeignKeys, err = SelectForeignKeys(conn, table.Name)
+		if err != nil {
+			return nil, err
+		}
+		parsedTable.PrimaryKeys, parsedTable.UniqueKeys, err = SelectKeys(conn, table.Name)
+		if err != nil {
+			return nil, err
+		}
+		parsedTables = append(parsedTables, parsedTable)
+	}
+	return parsedTables, nil
+}
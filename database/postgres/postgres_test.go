@@ -0,0 +1,215 @@
+package postgres
+
+import (
+	"database/sql"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMapPostgresType(t *testing.T) {
+	cases := map[string][2]string{
+		"int4":        {"integer", ""},
+		"int8":        {"integer", ""},
+		"numeric":     {"number", ""},
+		"varchar":     {"string", ""},
+		"bool":        {"boolean", ""},
+		"timestamptz": {"string", "date-time"},
+		"date":        {"string", "date"},
+		"uuid":        {"string", "uuid"},
+		"jsonb":       {"", ""},
+		"bytea":       {"string", ""},
+	}
+	for udtName, expected := range cases {
+		fieldType, err := MapPostgresType(udtName, nil)
+		assert.Nilf(t, err, "mapping %s should succeed", udtName)
+		assert.Equalf(t, expected[0], fieldType.Name, "the type of %s should be `%s`", udtName, expected[0])
+		assert.Equalf(t, expected[1], fieldType.Format, "the format of %s should be `%s`", udtName, expected[1])
+	}
+}
+
+func TestMapPostgresTypeBinary(t *testing.T) {
+	fieldType, err := MapPostgresType("bytea", nil)
+	assert.Nil(t, err, "mapping bytea should succeed")
+	assert.Equal(t, "base64", fieldType.ContentEncoding, "bytea should be base64 encoded")
+}
+
+func TestMapPostgresTypeArray(t *testing.T) {
+	fieldType, err := MapPostgresType("_text", nil)
+	assert.Nil(t, err, "mapping an array type should succeed")
+	assert.Equal(t, "array", fieldType.Name, "the type should be `array`")
+	assert.Equal(t, "string", fieldType.Items.Name, "the item type should be `string`")
+}
+
+func TestMapPostgresTypeEnum(t *testing.T) {
+	enums := map[string][]interface{}{"mood": {"sad", "ok", "happy"}}
+	fieldType, err := MapPostgresType("mood", enums)
+	assert.Nil(t, err, "mapping an enum type should succeed")
+	assert.Equal(t, "string", fieldType.Name, "the type should be `string`")
+	assert.Equal(t, []interface{}{"sad", "ok", "happy"}, fieldType.Enum, "the enum labels should be used")
+	fieldType, err = MapPostgresType("_mood", enums)
+	assert.Nil(t, err, "mapping an enum array type should succeed")
+	assert.Equal(t, []interface{}{"sad", "ok", "happy"}, fieldType.Items.Enum, "the enum labels should be used")
+}
+
+func TestSelectEnums(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.Nil(t, err, "creating the mock connection should succeed")
+	defer db.Close()
+	rows := sqlmock.NewRows([]string{"nspname", "typname", "enumlabel"}).
+		AddRow("public", "mood", "sad").
+		AddRow("public", "mood", "happy").
+		AddRow("public", "size", "small").
+		AddRow("shared", "mood", "calm")
+	mock.ExpectQuery("from pg_catalog.pg_enum").WillReturnRows(rows)
+	enums, err := SelectEnums(db)
+	assert.Nil(t, err, "selecting the enums should succeed")
+	assert.Equal(t, []interface{}{"sad", "happy"}, enums["public"]["mood"], "the labels should be grouped by type")
+	assert.Equal(t, []interface{}{"small"}, enums["public"]["size"], "the labels should be grouped by type")
+	assert.Equal(t, []interface{}{"calm"}, enums["shared"]["mood"], "the labels should be grouped by schema")
+	assert.Nil(t, mock.ExpectationsWereMet(), "all queries should be executed")
+}
+
+func TestMapPostgresTypeUnknown(t *testing.T) {
+	_, err := MapPostgresType("tsvector", nil)
+	assert.NotNil(t, err, "mapping an unknown type should fail")
+}
+
+func TestDescribeTable(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.Nil(t, err, "creating the mock connection should succeed")
+	defer db.Close()
+	columns := []string{
+		"column_name",
+		"udt_schema",
+		"udt_name",
+		"is_nullable",
+		"column_default",
+		"character_maximum_length",
+		"numeric_precision",
+		"numeric_scale",
+		"is_identity",
+		"is_generated",
+		"col_description",
+	}
+	rows := sqlmock.NewRows(columns).
+		AddRow("id", "pg_catalog", "int8", "NO", "nextval('albums_id_seq'::regclass)", nil, 64, 0, "NO", "NEVER", "").
+		AddRow("title", "pg_catalog", "varchar", "NO", "'Untitled'::character varying", 120, nil, nil, "NO", "NEVER", "The album title").
+		AddRow("tags", "pg_catalog", "_text", "YES", nil, nil, nil, nil, "NO", "NEVER", "").
+		AddRow("created_at", "pg_catalog", "timestamptz", "YES", "now()", nil, nil, nil, "NO", "NEVER", "").
+		AddRow("price", "pg_catalog", "numeric", "YES", nil, nil, 10, 2, "NO", "ALWAYS", "").
+		AddRow("search", "pg_catalog", "tsvector", "YES", nil, nil, nil, nil, "NO", "NEVER", "").
+		AddRow("mood", "shared", "mood", "YES", nil, nil, nil, nil, "NO", "NEVER", "")
+	mock.ExpectQuery("from information_schema.columns").
+		WithArgs("albums").
+		WillReturnRows(rows)
+	enums := map[string]map[string][]interface{}{"shared": {"mood": {"calm"}}}
+	table, err := DescribeTable(db, "albums", enums)
+	assert.Nil(t, err, "describing the table should succeed")
+	assert.Equal(t, "albums", table.Name, "the table name should be `albums`")
+	assert.Equal(t, 7, len(table.Fields), "the table should have 7 fields")
+	assert.Equal(t, "integer", table.Fields[0].Type.Name, "the field type should be `integer`")
+	assert.False(t, table.Fields[0].Nullable, "the field should not be nullable")
+	assert.True(t, table.Fields[2].Nullable, "the field should be nullable")
+	assert.Equal(t, "The album title", table.Fields[1].Comment, "the column comment should be read")
+	assert.Equal(t, "array", table.Fields[2].Type.Name, "the field type should be `array`")
+	assert.Equal(t, "date-time", table.Fields[3].Type.Format, "the field format should be `date-time`")
+	assert.Equal(t, 120, table.Fields[1].Type.MaxLength, "the max length should be 120")
+	assert.True(t, table.Fields[0].AutoIncrement, "serial columns should be auto-increment")
+	assert.False(t, table.Fields[1].AutoIncrement, "the field should not be auto-increment")
+	assert.True(t, table.Fields[4].Generated, "the field should be generated")
+	assert.False(t, table.Fields[3].Generated, "the field should not be generated")
+	assert.Equal(t, 0, table.Fields[0].Type.Precision, "only numeric should have a precision")
+	assert.Equal(t, 10, table.Fields[4].Type.Precision, "the precision should be 10")
+	assert.Equal(t, 2, table.Fields[4].Type.Scale, "the scale should be 2")
+	assert.Equal(t, "Untitled", table.Fields[1].Default, "the default should be unquoted")
+	assert.Equal(t, "now()", table.Fields[3].DefaultExpression, "the default expression should be kept")
+	assert.Equal(t, "varchar(120)", table.Fields[1].SQLType, "the SQL type should include the length")
+	assert.Equal(t, "numeric(10,2)", table.Fields[4].SQLType, "the SQL type should include the precision and scale")
+	assert.Equal(t, "int8", table.Fields[0].SQLType, "the SQL type should be the udt name")
+	assert.Empty(t, table.Fields[5].Type.Name, "columns of unknown types should be untyped")
+	assert.Equal(t, "tsvector", table.Fields[5].SQLType, "columns of unknown types should keep their SQL type")
+	assert.Equal(t, []interface{}{"calm"}, table.Fields[6].Type.Enum, "enum types should be looked up in their schema")
+	assert.Nil(t, mock.ExpectationsWereMet(), "all queries should be executed")
+}
+
+func TestParseDefault(t *testing.T) {
+	cases := []struct {
+		udtName    string
+		dflt       string
+		value      interface{}
+		expression string
+	}{
+		{"varchar", "'it''s'::character varying", "it's", ""},
+		{"int4", "42", int64(42), ""},
+		{"int4", "'-1'::integer", int64(-1), ""},
+		{"numeric", "(-1.5)", -1.5, ""},
+		{"bool", "true", true, ""},
+		{"varchar", "NULL::character varying", nil, ""},
+		{"jsonb", "'{}'::jsonb", map[string]interface{}{}, ""},
+		{"_text", "'{}'::text[]", nil, "'{}'::text[]"},
+		{"timestamptz", "CURRENT_TIMESTAMP", nil, "CURRENT_TIMESTAMP"},
+		{"int8", "nextval('albums_id_seq'::regclass)", nil, "nextval('albums_id_seq'::regclass)"},
+	}
+	for _, c := range cases {
+		fieldType, err := MapPostgresType(c.udtName, nil)
+		assert.Nilf(t, err, "mapping %s should succeed", c.udtName)
+		value, expression := ParseDefault(fieldType, sql.NullString{String: c.dflt, Valid: true})
+		assert.Equalf(t, c.value, value, "the default value of %s should be parsed", c.dflt)
+		assert.Equalf(t, c.expression, expression, "the default expression of %s should be kept", c.dflt)
+	}
+}
+
+func TestSelectTables(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.Nil(t, err, "creating the mock connection should succeed")
+	defer db.Close()
+	rows := sqlmock.NewRows([]string{"relname", "obj_description"}).
+		AddRow("albums", "Released albums").
+		AddRow("tracks", "")
+	mock.ExpectQuery("from pg_catalog.pg_class").WillReturnRows(rows)
+	tables, err := SelectTables(db)
+	assert.Nil(t, err, "selecting the tables should succeed")
+	assert.Equal(t, 2, len(tables), "there should be 2 tables")
+	assert.Equal(t, "albums", tables[0].Name, "the table name should be `albums`")
+	assert.Equal(t, "Released albums", tables[0].Comment, "the table comment should be read")
+	assert.Equal(t, "tracks", tables[1].Name, "the table name should be `tracks`")
+	assert.Nil(t, mock.ExpectationsWereMet(), "all queries should be executed")
+}
+
+func TestSelectForeignKeys(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.Nil(t, err, "creating the mock connection should succeed")
+	defer db.Close()
+	rows := sqlmock.NewRows([]string{"attname", "relname", "attname"}).
+		AddRow("album_id", "albums", "id")
+	mock.ExpectQuery("from pg_catalog.pg_constraint").
+		WithArgs("tracks").
+		WillReturnRows(rows)
+	foreignKeys, err := SelectForeignKeys(db, "tracks")
+	assert.Nil(t, err, "selecting the foreign keys should succeed")
+	assert.Equal(t, 1, len(foreignKeys), "there should be 1 foreign key")
+	assert.Equal(t, "album_id", foreignKeys[0].Field, "the field should be `album_id`")
+	assert.Equal(t, "albums", foreignKeys[0].ReferencedTable, "the referenced table should be `albums`")
+	assert.Equal(t, "id", foreignKeys[0].ReferencedField, "the referenced field should be `id`")
+	assert.Nil(t, mock.ExpectationsWereMet(), "all queries should be executed")
+}
+
+func TestSelectKeys(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.Nil(t, err, "creating the mock connection should succeed")
+	defer db.Close()
+	rows := sqlmock.NewRows([]string{"conname", "contype", "attname"}).
+		AddRow("tracks_pkey", "p", "album_id").
+		AddRow("tracks_pkey", "p", "position").
+		AddRow("tracks_isrc_key", "u", "isrc")
+	mock.ExpectQuery("from pg_catalog.pg_constraint").
+		WithArgs("tracks").
+		WillReturnRows(rows)
+	primaryKeys, uniqueKeys, err := SelectKeys(db, "tracks")
+	assert.Nil(t, err, "selecting the keys should succeed")
+	assert.Equal(t, []string{"album_id", "position"}, primaryKeys, "the primary key should be composite")
+	assert.Equal(t, [][]string{{"isrc"}}, uniqueKeys, "`isrc` should be unique")
+	assert.Nil(t, mock.ExpectationsWereMet(), "all queries should be executed")
+}
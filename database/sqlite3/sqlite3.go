@@ -3,11 +3,15 @@ package sqlite3
 import (
 	"database/sql"
 	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
 
 	"github.com/alecthomas/participle/v2"
 	"github.com/alecthomas/participle/v2/lexer"
 	"github.com/alecthomas/participle/v2/lexer/stateful"
 	_ "github.com/mattn/go-sqlite3"
+	log "github.com/sirupsen/logrus"
 	"github.com/tgallant/db2jsonschema/internal/schema"
 )
 
@@ -20,6 +24,29 @@ type SQLiteTable struct {
 	sql  string
 }
 
+// SQLiteColumn is a row of PRAGMA table_xinfo.
+type SQLiteColumn struct {
+	Name       string
+	Type       string
+	NotNull    bool
+	Default    sql.NullString
+	PrimaryKey int
+	Hidden     int
+}
+
+// Values of the hidden column of PRAGMA table_xinfo. Hidden columns of
+// virtual tables are skipped, generated columns are reported as virtual or
+// stored.
+const (
+	hiddenVirtualTableColumn = 1
+	hiddenVirtualColumn      = 2
+	hiddenStoredColumn       = 3
+)
+
+// expressionColumn is the cid of PRAGMA index_info for the key parts of an
+// index on an expression.
+const expressionColumn = -2
+
 type SQLiteCreateTable struct {
 	TableName        string                   `parser:"'CREATE' 'TABLE' ( 'IF' 'NOT' 'EXISTS' )? @Ident"`
 	FieldExpressions []*SQLiteFieldExpression `parser:"'(' @@ ( ',' @@ )* ( ',' )?"`
@@ -32,7 +59,7 @@ type SQLiteCreateTable struct {
 type SQLiteFieldExpression struct {
 	Name          string `parser:"@Ident"`
 	Type          string `parser:"@Ident"`
-	Limit         string `parser:"( '(' Number ')' )?"`
+	Limit         string `parser:"( '(' @Number ')' )?"`
 	NotNull       bool   `parser:"( @'NOT' 'NULL' | @'NOT_NULL'"`
 	Default       string `parser:"| 'DEFAULT' '(' @Ident ')'"`
 	AutoIncrement bool   `parser:"| @'AUTO_INCREMENT' )*"`
@@ -51,26 +78,44 @@ type SQLiteCheck struct {
 
 type SQLiteConstraint struct {
 	Name            string `parser:"@Ident"`
-	Key             string `parser:"('FOREIGN' | 'PRIMARY') 'KEY' '(' @Ident ')'"`
+	Kind            string `parser:"@('FOREIGN' | 'PRIMARY') 'KEY'"`
+	Key             string `parser:"'(' @Ident ')'"`
 	ReferencedTable string `parser:"('REFERENCES' @Ident)?"`
 	ReferencedField string `parser:"('(' @Ident ')')?"`
 }
 
 var (
-	typesMap = map[string]*schema.FieldType{
-		"int":      {Name: "number", Format: ""},
-		"INTEGER":  {Name: "number", Format: ""},
-		"integer":  {Name: "number", Format: ""},
-		"varchar":  {Name: "string", Format: ""},
-		"VARCHAR":  {Name: "string", Format: ""},
-		"text":     {Name: "string", Format: ""},
-		"boolean":  {Name: "boolean", Format: ""},
-		"BOOLEAN":  {Name: "boolean", Format: ""},
-		"numeric":  {Name: "boolean", Format: ""},
-		"datetime": {Name: "string", Format: "date-time"},
-		"DATETIME": {Name: "string", Format: "date-time"},
+	affinityTypes = map[string]schema.FieldType{
+		AffinityInteger: {Name: "integer", Format: "", Size: 64},
+		AffinityText:    {Name: "string", Format: ""},
+		AffinityBlob:    {Name: "string", Format: "", ContentEncoding: "base64"},
+		AffinityReal:    {Name: "number", Format: ""},
+		AffinityNumeric: {Name: "number", Format: ""},
 	}
 
+	// numericHeuristics refine NUMERIC affinity columns by their declared
+	// type name. Order matters since e.g. TIMESTAMP contains TIME.
+	numericHeuristics = []struct {
+		Pattern string
+		Type    schema.FieldType
+	}{
+		{"BOOL", schema.FieldType{Name: "boolean", Format: ""}},
+		{"DATETIME", schema.FieldType{Name: "string", Format: "date-time"}},
+		{"TIMESTAMP", schema.FieldType{Name: "string", Format: "date-time"}},
+		{"DATE", schema.FieldType{Name: "string", Format: "date"}},
+		{"TIME", schema.FieldType{Name: "string", Format: "time"}},
+		{"JSON", schema.FieldType{Name: "", Format: ""}},
+	}
+
+	createTableRegexp = regexp.MustCompile(`(?i)\bCREATE\s+(?:TEMP\w*\s+)?TABLE\b`)
+	leadingNameRegexp = regexp.MustCompile(`^[\s,(]*` + namePattern)
+
+	checkInRegexp = regexp.MustCompile(`(?i)\bCHECK\s*\(\s*` + namePattern + `\s+IN\s*\(((?:\s*(?:` + literalPattern + `)\s*,?)+)\)\s*\)`)
+	literalRegexp = regexp.MustCompile(literalPattern)
+
+	fixedLengthRegexp = regexp.MustCompile(`(?i)^\s*(?:NATIVE\s+|N)?CHAR(?:ACTER)?\s*\(`)
+	typeArgsRegexp    = regexp.MustCompile(`\(\s*([-+]?\d+)\s*(?:,\s*([-+]?\d+)\s*)?\)`)
+
 	sqlLexer = lexer.Must(stateful.NewSimple([]stateful.Rule{
 		{Name: `Keyword`, Pattern: `(?i)\b(CREATE|TABLE|PRIMARY|FOREIGN|KEY|CONSTRAINT|REFERENCE|CHECK|IN)\b`, Action: nil},
 		{Name: `Ident`, Pattern: `[a-zA-Z_][a-zA-Z0-9_]*`, Action: nil},
@@ -87,18 +132,252 @@ var (
 		participle.Lexer(sqlLexer),
 		participle.Unquote("String"),
 	)
+
+	withoutRowidRegexp = regexp.MustCompile(`(?i)\)\s*WITHOUT\s+ROWID\s*;?\s*$`)
+	generatedRegexp    = regexp.MustCompile(`(?i)\s*GENERATED\s+ALWAYS\s*$`)
+)
+
+const (
+	// namePattern matches a bare or quoted identifier into one of four
+	// groups, see unquoteName.
+	namePattern    = "(?:\"((?:[^\"]|\"\")*)\"|`([^`]*)`|\\[([^\\]]*)\\]|([A-Za-z_][A-Za-z0-9_$]*))"
+	literalPattern = `'(?:[^']|'')*'|[-+]?(?:\d+\.?\d*|\.\d+)(?:[eE][-+]?\d+)?`
+)
+
+const (
+	AffinityInteger = "INTEGER"
+	AffinityText    = "TEXT"
+	AffinityBlob    = "BLOB"
+	AffinityReal    = "REAL"
+	AffinityNumeric = "NUMERIC"
 )
 
+// TypeAffinity determines the affinity of a declared column type using the
+// rules from https://www.sqlite.org/datatype3.html#determination_of_column_affinity
+func TypeAffinity(t string) string {
+	t = strings.ToUpper(t)
+	switch {
+	case strings.Contains(t, "INT"):
+		return AffinityInteger
+	case strings.Contains(t, "CHAR"), strings.Contains(t, "CLOB"), strings.Contains(t, "TEXT"):
+		return AffinityText
+	case strings.Contains(t, "BLOB"), len(strings.TrimSpace(t)) == 0:
+		return AffinityBlob
+	case strings.Contains(t, "REAL"), strings.Contains(t, "FLOA"), strings.Contains(t, "DOUB"):
+		return AffinityReal
+	default:
+		return AffinityNumeric
+	}
+}
+
+// MapSQLiteType maps a declared column type onto a schema.FieldType based on
+// its affinity. Any declared type is accepted since SQLite accepts any type
+// name.
 func MapSQLiteType(t string) (*schema.FieldType, error) {
-	schemaType, exists := typesMap[t]
-	if !exists {
-		return &schema.FieldType{}, fmt.Errorf("Unknown data type: %s", t)
+	if len(strings.TrimSpace(t)) == 0 {
+		// Columns without a declared type can hold any value.
+		return &schema.FieldType{}, nil
+	}
+	affinity := TypeAffinity(t)
+	fieldType := affinityTypes[affinity]
+	if affinity == AffinityNumeric {
+		upper := strings.ToUpper(t)
+		for _, heuristic := range numericHeuristics {
+			if strings.Contains(upper, heuristic.Pattern) {
+				fieldType = heuristic.Type
+				break
+			}
+		}
+	}
+	if affinity == AffinityNumeric && fieldType.Name == "number" {
+		args := typeArgsRegexp.FindStringSubmatch(t)
+		if args != nil {
+			precision, err := strconv.Atoi(args[1])
+			if err != nil {
+				return &schema.FieldType{}, err
+			}
+			fieldType.Precision = precision
+			if len(args[2]) > 0 {
+				fieldType.Scale, err = strconv.Atoi(args[2])
+				if err != nil {
+					return &schema.FieldType{}, err
+				}
+			}
+		}
+	}
+	if affinity == AffinityText {
+		args := typeArgsRegexp.FindStringSubmatch(t)
+		if args != nil {
+			maxLength, err := strconv.Atoi(args[1])
+			if err != nil {
+				return &schema.FieldType{}, err
+			}
+			fieldType.MaxLength = maxLength
+			fieldType.FixedLength = fixedLengthRegexp.MatchString(t)
+		}
+	}
+	return &fieldType, nil
+}
+
+// splitLineComment splits a line of SQL into its code and the text of a
+// trailing `--` comment, ignoring dashes inside quoted strings and names.
+func splitLineComment(line string) (string, string, bool) {
+	var quote rune
+	for i, c := range line {
+		switch {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '\'' || c == '"' || c == '`':
+			quote = c
+		case c == '[':
+			quote = ']'
+		case c == '-' && strings.HasPrefix(line[i:], "--"):
+			return line[:i], strings.TrimSpace(line[i+2:]), true
+		}
+	}
+	return line, "", false
+}
+
+// unquoteName returns the identifier captured by the groups of namePattern.
+func unquoteName(groups []string) string {
+	return strings.ReplaceAll(groups[0], `""`, `"`) + groups[1] + groups[2] + groups[3]
+}
+
+// lookupColumn finds a column by name the way SQLite does, ignoring case.
+func lookupColumn(columns []string, name string) string {
+	for _, c := range columns {
+		if strings.EqualFold(c, name) {
+			return c
+		}
+	}
+	return ""
+}
+
+// ParseLiteral converts a SQL string or numeric literal into its JSON value.
+func ParseLiteral(literal string) (interface{}, bool) {
+	if strings.HasPrefix(literal, "'") && strings.HasSuffix(literal, "'") && len(literal) > 1 {
+		return strings.ReplaceAll(literal[1:len(literal)-1], "''", "'"), true
+	}
+	if value, err := strconv.ParseInt(literal, 10, 64); err == nil {
+		return value, true
+	}
+	if value, err := strconv.ParseFloat(literal, 64); err == nil {
+		return value, true
+	}
+	return nil, false
+}
+
+// ParseDefault converts the dflt_value of a column into the JSON value of
+// the default. Defaults that are not literals, such as CURRENT_TIMESTAMP or
+// (datetime('now')), are returned as an expression instead.
+func ParseDefault(t *schema.FieldType, dflt sql.NullString) (interface{}, string) {
+	if !dflt.Valid {
+		return nil, ""
+	}
+	expression := strings.TrimSpace(dflt.String)
+	literal := expression
+	switch strings.ToUpper(expression) {
+	case "NULL":
+		return nil, ""
+	case "TRUE":
+		literal = "1"
+	case "FALSE":
+		literal = "0"
+	default:
+		if literalRegexp.FindString(expression) != expression {
+			return nil, expression
+		}
+		if value, ok := ParseLiteral(expression); ok {
+			if text, isString := value.(string); isString {
+				literal = text
+			}
+		}
+	}
+	value, ok := schema.ConvertLiteral(t, literal)
+	if !ok {
+		return nil, expression
+	}
+	return value, ""
+}
+
+// ParseChecks reads the `CHECK (column IN (...))` constraints of a CREATE
+// TABLE statement into the values allowed for each column.
+func ParseChecks(tableSQL string, columns []string) map[string][]interface{} {
+	var checks = make(map[string][]interface{})
+	for _, matches := range checkInRegexp.FindAllStringSubmatch(tableSQL, -1) {
+		column := lookupColumn(columns, unquoteName(matches[1:5]))
+		if len(column) == 0 {
+			continue
+		}
+		var values []interface{}
+		for _, literal := range literalRegexp.FindAllString(matches[5], -1) {
+			value, _ := ParseLiteral(literal)
+			values = append(values, value)
+		}
+		checks[column] = values
+	}
+	return checks
+}
+
+// applyChecks restricts fields to the values allowed by their CHECK
+// constraints. Boolean columns are skipped since ORMs emulate them with a
+// CHECK (column IN (0, 1)) that would contradict the boolean type.
+func applyChecks(fields []*schema.Field, checks map[string][]interface{}) {
+	for _, field := range fields {
+		values, exists := checks[field.Name]
+		if !exists || field.Type.Name == "boolean" {
+			continue
+		}
+		field.Type.Enum = values
+	}
+}
+
+// ParseComments reads the `--` comments of a CREATE TABLE statement. A
+// comment on the CREATE TABLE line describes the table. A comment at the end
+// of a column definition, or on the lines right before it, describes the
+// column.
+func ParseComments(tableSQL string, columns []string) (string, map[string]string) {
+	var tableComment string
+	var columnComments = make(map[string]string)
+	var pending []string
+	for _, line := range strings.Split(tableSQL, "\n") {
+		code, comment, hasComment := splitLineComment(line)
+		if len(strings.TrimSpace(code)) == 0 {
+			if hasComment {
+				pending = append(pending, comment)
+			}
+			continue
+		}
+		if createTableRegexp.MatchString(code) {
+			if hasComment {
+				tableComment = comment
+			}
+			pending = nil
+			continue
+		}
+		if hasComment {
+			pending = append(pending, comment)
+		}
+		var column string
+		if matches := leadingNameRegexp.FindStringSubmatch(code); matches != nil {
+			column = lookupColumn(columns, unquoteName(matches[1:5]))
+		}
+		if len(column) > 0 && len(pending) > 0 {
+			columnComments[column] = strings.Join(pending, " ")
+		}
+		pending = nil
 	}
-	return schemaType, nil
+	return tableComment, columnComments
 }
 
 func SelectTables(conn *sql.DB) ([]*SQLiteTable, error) {
-	row, err := conn.Query(`select name, sql from sqlite_master where type = "table"`)
+	row, err := conn.Query(`
+select name, sql
+from sqlite_master
+where type = 'table' and name not like 'sqlite\_%' escape '\'
+order by name`)
 	if err != nil {
 		return nil, err
 	}
@@ -125,24 +404,290 @@ func ParseTableSQL(tableSQL string) (*schema.Table, error) {
 	}
 	var fields []*schema.Field
 	for _, fieldExpression := range createTable.FieldExpressions {
-		schemaType, err := MapSQLiteType(fieldExpression.Type)
+		declaredType := fieldExpression.Type
+		if len(fieldExpression.Limit) > 0 {
+			declaredType = fmt.Sprintf("%s(%s)", declaredType, fieldExpression.Limit)
+		}
+		schemaType, err := MapSQLiteType(declaredType)
 		if err != nil {
 			return &schema.Table{}, err
 		}
 		field := &schema.Field{
-			Name: fieldExpression.Name,
-			Type: schemaType,
+			Name:              fieldExpression.Name,
+			Type:              schemaType,
+			Nullable:          !fieldExpression.NotNull,
+			AutoIncrement:     fieldExpression.AutoIncrement,
+			DefaultExpression: fieldExpression.Default,
 		}
 		fields = append(fields, field)
 	}
+	var foreignKeys []*schema.ForeignKey
+	for _, fk := range createTable.ForeignKeys {
+		foreignKey := &schema.ForeignKey{
+			Field:           fk.ForeignKey,
+			ReferencedTable: fk.ReferencedTable,
+			ReferencedField: fk.ReferencedField,
+		}
+		foreignKeys = append(foreignKeys, foreignKey)
+	}
+	for _, c := range createTable.Constraints {
+		if c.Kind != "FOREIGN" || len(c.ReferencedTable) == 0 {
+			continue
+		}
+		foreignKey := &schema.ForeignKey{
+			Field:           c.Key,
+			ReferencedTable: c.ReferencedTable,
+			ReferencedField: c.ReferencedField,
+		}
+		foreignKeys = append(foreignKeys, foreignKey)
+	}
 	table := &schema.Table{
 		Name:        createTable.TableName,
 		Fields:      fields,
 		PrimaryKeys: createTable.PrimaryKeys,
+		ForeignKeys: foreignKeys,
 	}
 	return table, nil
 }
 
+// supplementTable adds the AUTO_INCREMENT attributes read by ParseTableSQL,
+// which the pragmas report as part of the declared type. The parser only
+// understands a subset of SQLite, so tables it rejects are left as is.
+func supplementTable(table *schema.Table, tableSQL string) {
+	parsedTable, err := ParseTableSQL(tableSQL)
+	if err != nil {
+		log.Debugf("Could not parse the CREATE TABLE statement of %s: %s", table.Name, err)
+		return
+	}
+	for _, parsedField := range parsedTable.Fields {
+		for _, field := range table.Fields {
+			if field.Name == parsedField.Name && parsedField.AutoIncrement {
+				field.AutoIncrement = true
+			}
+		}
+	}
+}
+
+func SelectColumns(conn *sql.DB, tableName string) ([]*SQLiteColumn, error) {
+	row, err := conn.Query(`
+select name, type, "notnull", dflt_value, pk, hidden
+from pragma_table_xinfo(?)
+order by cid`, tableName)
+	if err != nil {
+		return nil, err
+	}
+	defer row.Close()
+	var columns []*SQLiteColumn
+	for row.Next() {
+		column := &SQLiteColumn{}
+		err = row.Scan(
+			&column.Name,
+			&column.Type,
+			&column.NotNull,
+			&column.Default,
+			&column.PrimaryKey,
+			&column.Hidden,
+		)
+		if err != nil {
+			return nil, err
+		}
+		if column.Hidden == hiddenVirtualTableColumn {
+			continue
+		}
+		// The declared type of a generated column includes the
+		// GENERATED ALWAYS clause.
+		column.Type = generatedRegexp.ReplaceAllString(column.Type, "")
+		columns = append(columns, column)
+	}
+	return columns, nil
+}
+
+// SelectPrimaryKeys returns the primary key columns of a table in key order.
+func SelectPrimaryKeys(conn *sql.DB, tableName string) ([]string, error) {
+	row, err := conn.Query(`
+select name
+from pragma_table_info(?)
+where pk > 0
+order by pk`, tableName)
+	if err != nil {
+		return nil, err
+	}
+	defer row.Close()
+	var primaryKeys []string
+	for row.Next() {
+		var name string
+		err = row.Scan(&name)
+		if err != nil {
+			return nil, err
+		}
+		primaryKeys = append(primaryKeys, name)
+	}
+	return primaryKeys, nil
+}
+
+func SelectForeignKeys(conn *sql.DB, tableName string) ([]*schema.ForeignKey, error) {
+	row, err := conn.Query(`
+select "from", "table", "to"
+from pragma_foreign_key_list(?)
+order by id, seq`, tableName)
+	if err != nil {
+		return nil, err
+	}
+	defer row.Close()
+	var foreignKeys []*schema.ForeignKey
+	var implicit []*schema.ForeignKey
+	for row.Next() {
+		foreignKey := &schema.ForeignKey{}
+		var referencedField sql.NullString
+		err = row.Scan(
+			&foreignKey.Field,
+			&foreignKey.ReferencedTable,
+			&referencedField,
+		)
+		if err != nil {
+			return nil, err
+		}
+		foreignKey.ReferencedField = referencedField.String
+		if !referencedField.Valid {
+			implicit = append(implicit, foreignKey)
+		}
+		foreignKeys = append(foreignKeys, foreignKey)
+	}
+	row.Close()
+	// REFERENCES without a column list refers to the primary key of the
+	// referenced table.
+	for _, foreignKey := range implicit {
+		primaryKeys, err := SelectPrimaryKeys(conn, foreignKey.ReferencedTable)
+		if err != nil {
+			return nil, err
+		}
+		if len(primaryKeys) > 0 {
+			foreignKey.ReferencedField = primaryKeys[0]
+		}
+	}
+	return foreignKeys, nil
+}
+
+// SelectUniqueKeys returns the columns of every UNIQUE constraint and
+// unique index of a table. Partial indexes are skipped since they do not
+// constrain every row, and so are indexes on expressions since they do not
+// constrain the columns themselves.
+func SelectUniqueKeys(conn *sql.DB, tableName string) ([][]string, error) {
+	row, err := conn.Query(`
+select name
+from pragma_index_list(?)
+where "unique" = 1 and origin != 'pk' and partial = 0
+order by seq desc`, tableName)
+	if err != nil {
+		return nil, err
+	}
+	defer row.Close()
+	var indexes []string
+	for row.Next() {
+		var name string
+		err = row.Scan(&name)
+		if err != nil {
+			return nil, err
+		}
+		indexes = append(indexes, name)
+	}
+	row.Close()
+	var uniqueKeys [][]string
+	for _, index := range indexes {
+		columns, err := conn.Query(`
+select cid, name
+from pragma_index_info(?)
+order by seqno`, index)
+		if err != nil {
+			return nil, err
+		}
+		var uniqueKey []string
+		var expression bool
+		for columns.Next() {
+			var cid int
+			var name sql.NullString
+			err = columns.Scan(&cid, &name)
+			if err != nil {
+				columns.Close()
+				return nil, err
+			}
+			if cid == expressionColumn {
+				expression = true
+			}
+			uniqueKey = append(uniqueKey, name.String)
+		}
+		columns.Close()
+		if !expression {
+			uniqueKeys = append(uniqueKeys, uniqueKey)
+		}
+	}
+	return uniqueKeys, nil
+}
+
+// DescribeTable introspects a table with the table_xinfo, foreign_key_list
+// and index_list pragmas, which work for any table SQLite accepts.
+func DescribeTable(conn *sql.DB, table *SQLiteTable) (*schema.Table, error) {
+	columns, err := SelectColumns(conn, table.name)
+	if err != nil {
+		return nil, err
+	}
+	primaryKeys, err := SelectPrimaryKeys(conn, table.name)
+	if err != nil {
+		return nil, err
+	}
+	withoutRowid := withoutRowidRegexp.MatchString(table.sql)
+	var fields []*schema.Field
+	for _, column := range columns {
+		schemaType, err := MapSQLiteType(column.Type)
+		if err != nil {
+			return nil, err
+		}
+		// Primary keys of WITHOUT ROWID tables and INTEGER PRIMARY KEY
+		// columns (aliases of the rowid) can never be NULL. The rowid is
+		// assigned on insert when no value is given.
+		isRowid := column.PrimaryKey > 0 && !withoutRowid &&
+			len(primaryKeys) == 1 && strings.EqualFold(column.Type, "INTEGER")
+		isKey := column.PrimaryKey > 0 && withoutRowid
+		field := &schema.Field{
+			Name:          column.Name,
+			Type:          schemaType,
+			SQLType:       column.Type,
+			Nullable:      !column.NotNull && !isKey && !isRowid,
+			AutoIncrement: isRowid,
+			Generated:     column.Hidden == hiddenVirtualColumn || column.Hidden == hiddenStoredColumn,
+		}
+		field.Default, field.DefaultExpression = ParseDefault(schemaType, column.Default)
+		fields = append(fields, field)
+	}
+	foreignKeys, err := SelectForeignKeys(conn, table.name)
+	if err != nil {
+		return nil, err
+	}
+	uniqueKeys, err := SelectUniqueKeys(conn, table.name)
+	if err != nil {
+		return nil, err
+	}
+	var columnNames []string
+	for _, column := range columns {
+		columnNames = append(columnNames, column.Name)
+	}
+	tableComment, columnComments := ParseComments(table.sql, columnNames)
+	for _, field := range fields {
+		field.Comment = columnComments[field.Name]
+	}
+	applyChecks(fields, ParseChecks(table.sql, columnNames))
+	parsedTable := &schema.Table{
+		Name:        table.name,
+		Comment:     tableComment,
+		Fields:      fields,
+		PrimaryKeys: primaryKeys,
+		ForeignKeys: foreignKeys,
+		UniqueKeys:  uniqueKeys,
+	}
+	supplementTable(parsedTable, table.sql)
+	return parsedTable, nil
+}
+
 func (d *Driver) ReadTables() ([]*schema.Table, error) {
 	conn, err := sql.Open("sqlite3", d.DataSource)
 	if err != nil {
@@ -155,7 +700,7 @@ func (d *Driver) ReadTables() ([]*schema.Table, error) {
 	}
 	var parsedTables []*schema.Table
 	for _, table := range tables {
-		parsedTable, err := ParseTableSQL(table.sql)
+		parsedTable, err := DescribeTable(conn, table)
 		if err != nil {
 			return nil, err
 		}
@@ -1,8 +1,11 @@
 package sqlite3
 
 import (
-	"github.com/stretchr/testify/assert"
+	"database/sql"
+	"path/filepath"
 	"testing"
+
+	"github.com/stretchr/testify/assert"
 )
 
 func TestParseTableSQLSimple(t *testing.T) {
@@ -13,10 +16,11 @@ func TestParseTableSQLSimple(t *testing.T) {
 	assert.Equal(t, 2, len(table.Fields), "the table should have 2 fields")
 	firstField := table.Fields[0]
 	assert.Equal(t, "id", firstField.Name, "the field name should be `id`")
-	assert.Equal(t, "number", firstField.Type.Name, "the field type should be `number`")
+	assert.Equal(t, "integer", firstField.Type.Name, "the field type should be `integer`")
 	secondField := table.Fields[1]
 	assert.Equal(t, "name", secondField.Name, "the field name should be `name`")
 	assert.Equal(t, "string", secondField.Type.Name, "the field type should be `string`")
+	assert.Equal(t, 255, secondField.Type.MaxLength, "the max length should be 255")
 }
 
 func TestParseTableSQLSimpleIfNotExists(t *testing.T) {
@@ -27,7 +31,7 @@ func TestParseTableSQLSimpleIfNotExists(t *testing.T) {
 	assert.Equal(t, 2, len(table.Fields), "the table should have 2 fields")
 	firstField := table.Fields[0]
 	assert.Equal(t, "id", firstField.Name, "the field name should be `id`")
-	assert.Equal(t, "number", firstField.Type.Name, "the field type should be `number`")
+	assert.Equal(t, "integer", firstField.Type.Name, "the field type should be `integer`")
 	secondField := table.Fields[1]
 	assert.Equal(t, "name", secondField.Name, "the field name should be `name`")
 	assert.Equal(t, "string", secondField.Type.Name, "the field type should be `string`")
@@ -45,7 +49,7 @@ CREATE TABLE Example (
 	assert.Equal(t, 2, len(table.Fields), "the table should have 2 fields")
 	firstField := table.Fields[0]
 	assert.Equal(t, "id", firstField.Name, "the field name should be `id`")
-	assert.Equal(t, "number", firstField.Type.Name, "the field type should be `number`")
+	assert.Equal(t, "integer", firstField.Type.Name, "the field type should be `integer`")
 	secondField := table.Fields[1]
 	assert.Equal(t, "name", secondField.Name, "the field name should be `name`")
 	assert.Equal(t, "string", secondField.Type.Name, "the field type should be `string`")
@@ -59,7 +63,7 @@ func TestParseTableSQLWithBackticks(t *testing.T) {
 	assert.Equal(t, 2, len(table.Fields), "the table should have 2 fields")
 	firstField := table.Fields[0]
 	assert.Equal(t, "id", firstField.Name, "the field name should be `id`")
-	assert.Equal(t, "number", firstField.Type.Name, "the field type should be `number`")
+	assert.Equal(t, "integer", firstField.Type.Name, "the field type should be `integer`")
 	secondField := table.Fields[1]
 	assert.Equal(t, "name", secondField.Name, "the field name should be `name`")
 	assert.Equal(t, "string", secondField.Type.Name, "the field type should be `string`")
@@ -73,7 +77,7 @@ func TestParseTableSQLWithPrimaryKey(t *testing.T) {
 	assert.Equal(t, 2, len(table.Fields), "the table should have 2 fields")
 	firstField := table.Fields[0]
 	assert.Equal(t, "id", firstField.Name, "the field name should be `id`")
-	assert.Equal(t, "number", firstField.Type.Name, "the field type should be `number`")
+	assert.Equal(t, "integer", firstField.Type.Name, "the field type should be `integer`")
 	secondField := table.Fields[1]
 	assert.Equal(t, "name", secondField.Name, "the field name should be `name`")
 	assert.Equal(t, "string", secondField.Type.Name, "the field type should be `string`")
@@ -97,7 +101,7 @@ CREATE TABLE Example (
 	assert.Equal(t, 3, len(table.Fields), "the table should have 3 fields")
 	firstField := table.Fields[0]
 	assert.Equal(t, "id", firstField.Name, "the field name should be `id`")
-	assert.Equal(t, "number", firstField.Type.Name, "the field type should be `number`")
+	assert.Equal(t, "integer", firstField.Type.Name, "the field type should be `integer`")
 	secondField := table.Fields[1]
 	assert.Equal(t, "name", secondField.Name, "the field name should be `name`")
 	assert.Equal(t, "string", secondField.Type.Name, "the field type should be `string`")
@@ -127,16 +131,16 @@ CREATE TABLE Example (
 	assert.Equal(t, 5, len(table.Fields), "the table should have 5 fields")
 	firstField := table.Fields[0]
 	assert.Equal(t, "id", firstField.Name, "the field name should be `id`")
-	assert.Equal(t, "number", firstField.Type.Name, "the field type should be `number`")
+	assert.Equal(t, "integer", firstField.Type.Name, "the field type should be `integer`")
 	secondField := table.Fields[1]
 	assert.Equal(t, "name", secondField.Name, "the field name should be `name`")
 	assert.Equal(t, "string", secondField.Type.Name, "the field type should be `string`")
 	thirdField := table.Fields[2]
 	assert.Equal(t, "user_id", thirdField.Name, "the field name should be `user_id`")
-	assert.Equal(t, "number", thirdField.Type.Name, "the field type should be `number`")
+	assert.Equal(t, "integer", thirdField.Type.Name, "the field type should be `integer`")
 	fourthField := table.Fields[3]
 	assert.Equal(t, "team_id", fourthField.Name, "the field name should be `team_id`")
-	assert.Equal(t, "number", fourthField.Type.Name, "the field type should be `number`")
+	assert.Equal(t, "integer", fourthField.Type.Name, "the field type should be `integer`")
 	fifthField := table.Fields[4]
 	assert.Equal(t, "created_at", fifthField.Name, "the field name should be `created_at`")
 	assert.Equal(t, "string", fifthField.Type.Name, "the field type should be `string`")
@@ -165,7 +169,7 @@ CREATE TABLE IF NOT EXISTS "Condition" (
 	assert.Equal(t, 8, len(table.Fields), "the table should have 5 fields")
 	firstField := table.Fields[0]
 	assert.Equal(t, "id", firstField.Name, "the field name should be `id`")
-	assert.Equal(t, "number", firstField.Type.Name, "the field type should be `number`")
+	assert.Equal(t, "integer", firstField.Type.Name, "the field type should be `integer`")
 }
 
 func TestParseTableSQLFromAlembic(t *testing.T) {
@@ -182,5 +186,294 @@ CREATE TABLE "WorkflowTemplate" (
 	assert.Equal(t, 3, len(table.Fields), "the table should have 5 fields")
 	firstField := table.Fields[0]
 	assert.Equal(t, "id", firstField.Name, "the field name should be `id`")
-	assert.Equal(t, "number", firstField.Type.Name, "the field type should be `number`")
+	assert.Equal(t, "integer", firstField.Type.Name, "the field type should be `integer`")
+}
+
+func TestParseTableSQLNullable(t *testing.T) {
+	exampleTable := `
+CREATE TABLE Example (
+  id int NOT_NULL AUTO_INCREMENT,
+  name varchar(255) NOT NULL,
+  description text
+)`
+	table, err := ParseTableSQL(exampleTable)
+	assert.Nil(t, err, "parsing the table sql should succeed")
+	assert.Equal(t, 3, len(table.Fields), "the table should have 3 fields")
+	assert.False(t, table.Fields[0].Nullable, "the `id` field should not be nullable")
+	assert.False(t, table.Fields[1].Nullable, "the `name` field should not be nullable")
+	assert.True(t, table.Fields[2].Nullable, "the `description` field should be nullable")
+}
+
+func TestParseTableSQLForeignKeys(t *testing.T) {
+	exampleTable := `
+CREATE TABLE Example (
+  id int NOT_NULL AUTO_INCREMENT,
+  user_id integer,
+  team_id integer,
+  "WorkflowId" integer,
+  PRIMARY KEY (id),
+  FOREIGN KEY("WorkflowId") REFERENCES "Workflow" (id),
+  CONSTRAINT fk_user_id FOREIGN KEY (user_id) REFERENCES user(id),
+  CONSTRAINT fk_team_id FOREIGN KEY (team_id) REFERENCES team(id)
+)`
+	table, err := ParseTableSQL(exampleTable)
+	assert.Nil(t, err, "parsing the table sql should succeed")
+	assert.Equal(t, 3, len(table.ForeignKeys), "the table should have 3 foreign keys")
+	firstForeignKey := table.ForeignKeys[0]
+	assert.Equal(t, "WorkflowId", firstForeignKey.Field, "the foreign key field should be `WorkflowId`")
+	assert.Equal(t, "Workflow", firstForeignKey.ReferencedTable, "the referenced table should be `Workflow`")
+	assert.Equal(t, "id", firstForeignKey.ReferencedField, "the referenced field should be `id`")
+	secondForeignKey := table.ForeignKeys[1]
+	assert.Equal(t, "user_id", secondForeignKey.Field, "the foreign key field should be `user_id`")
+	assert.Equal(t, "user", secondForeignKey.ReferencedTable, "the referenced table should be `user`")
+}
+
+func makeTestDB(t *testing.T, statements ...string) *sql.DB {
+	dataSource := filepath.Join(t.TempDir(), "test.db")
+	conn, err := sql.Open("sqlite3", dataSource)
+	assert.Nil(t, err, "opening the database should succeed")
+	for _, statement := range statements {
+		_, err = conn.Exec(statement)
+		assert.Nilf(t, err, "executing %s should succeed", statement)
+	}
+	return conn
+}
+
+func TestDescribeTable(t *testing.T) {
+	tableSQL := `
+CREATE TABLE "order items" (
+  "item id" INTEGER PRIMARY KEY,
+  sku VARCHAR(32) NOT NULL UNIQUE COLLATE NOCASE,
+  label text DEFAULT 'n/a',
+  quantity int CHECK (quantity > 0),
+  total numeric GENERATED ALWAYS AS (quantity * 2) VIRTUAL
+)`
+	conn := makeTestDB(t, tableSQL)
+	defer conn.Close()
+	tables, err := SelectTables(conn)
+	assert.Nil(t, err, "selecting the tables should succeed")
+	assert.Equal(t, 1, len(tables), "there should be 1 table")
+	table, err := DescribeTable(conn, tables[0])
+	assert.Nil(t, err, "describing the table should succeed")
+	assert.Equal(t, "order items", table.Name, "the table name should be `order items`")
+	assert.Equal(t, 5, len(table.Fields), "the table should have 5 fields")
+	firstField := table.Fields[0]
+	assert.Equal(t, "item id", firstField.Name, "the field name should be `item id`")
+	assert.False(t, firstField.Nullable, "the rowid alias should not be nullable")
+	assert.True(t, firstField.AutoIncrement, "the rowid alias should be auto-increment")
+	secondField := table.Fields[1]
+	assert.Equal(t, "sku", secondField.Name, "the field name should be `sku`")
+	assert.Equal(t, "string", secondField.Type.Name, "the field type should be `string`")
+	assert.Equal(t, "VARCHAR(32)", secondField.SQLType, "the SQL type should be the declared type")
+	assert.False(t, secondField.Nullable, "the field should not be nullable")
+	assert.True(t, table.Fields[2].Nullable, "the field should be nullable")
+	assert.Equal(t, "n/a", table.Fields[2].Default, "the default should be unquoted")
+	assert.Equal(t, "total", table.Fields[4].Name, "generated columns should be included")
+	assert.True(t, table.Fields[4].Generated, "the field should be generated")
+	assert.False(t, table.Fields[3].Generated, "the field should not be generated")
+	assert.Equal(t, []string{"item id"}, table.PrimaryKeys, "the primary key should be `item id`")
+	assert.Equal(t, [][]string{{"sku"}}, table.UniqueKeys, "`sku` should be unique")
+}
+
+func TestSelectUniqueKeys(t *testing.T) {
+	conn := makeTestDB(t,
+		"CREATE TABLE accounts (a text, b text, c text UNIQUE)",
+		"CREATE UNIQUE INDEX accounts_a_b ON accounts (lower(a), b)",
+		"CREATE UNIQUE INDEX accounts_b ON accounts (b) WHERE a IS NOT NULL",
+	)
+	defer conn.Close()
+	uniqueKeys, err := SelectUniqueKeys(conn, "accounts")
+	assert.Nil(t, err, "selecting the unique keys should succeed")
+	assert.Equal(t, [][]string{{"c"}}, uniqueKeys, "indexes on expressions and partial indexes should be skipped")
+}
+
+func TestDescribeTableWithoutRowid(t *testing.T) {
+	tableSQL := `
+CREATE TABLE memberships (
+  user_id int REFERENCES users,
+  team_id int,
+  role text,
+  PRIMARY KEY (user_id, team_id),
+  FOREIGN KEY (team_id) REFERENCES teams (id)
+) WITHOUT ROWID`
+	conn := makeTestDB(t,
+		"CREATE TABLE users (id INTEGER PRIMARY KEY)",
+		"CREATE TABLE teams (id INTEGER PRIMARY KEY)",
+		tableSQL,
+	)
+	defer conn.Close()
+	table, err := DescribeTable(conn, &SQLiteTable{"memberships", tableSQL})
+	assert.Nil(t, err, "describing the table should succeed")
+	assert.Equal(t, []string{"user_id", "team_id"}, table.PrimaryKeys, "the primary key should be composite")
+	assert.False(t, table.Fields[0].Nullable, "primary keys of WITHOUT ROWID tables should not be nullable")
+	assert.False(t, table.Fields[0].AutoIncrement, "WITHOUT ROWID tables have no rowid")
+	assert.False(t, table.Fields[1].Nullable, "primary keys of WITHOUT ROWID tables should not be nullable")
+	assert.True(t, table.Fields[2].Nullable, "the field should be nullable")
+	assert.Equal(t, 2, len(table.ForeignKeys), "the table should have 2 foreign keys")
+	for _, foreignKey := range table.ForeignKeys {
+		assert.Equal(t, "id", foreignKey.ReferencedField, "the referenced field should be `id`")
+	}
+}
+
+func TestTypeAffinity(t *testing.T) {
+	cases := map[string]string{
+		"INT":               AffinityInteger,
+		"BIGINT":            AffinityInteger,
+		"UNSIGNED BIG INT":  AffinityInteger,
+		"VARCHAR(255)":      AffinityText,
+		"NCHAR(55)":         AffinityText,
+		"CLOB":              AffinityText,
+		"BLOB":              AffinityBlob,
+		"":                  AffinityBlob,
+		"REAL":              AffinityReal,
+		"DOUBLE PRECISION":  AffinityReal,
+		"FLOAT":             AffinityReal,
+		"NUMERIC":           AffinityNumeric,
+		"DECIMAL(10,5)":     AffinityNumeric,
+		"BOOLEAN":           AffinityNumeric,
+		"DATETIME":          AffinityNumeric,
+		"CHARINT":           AffinityInteger,
+		"FLOATING POINT":    AffinityInteger,
+		"STRING":            AffinityNumeric,
+		"varying character": AffinityText,
+	}
+	for declaredType, expected := range cases {
+		assert.Equalf(t, expected, TypeAffinity(declaredType), "the affinity of `%s` should be %s", declaredType, expected)
+	}
+}
+
+func TestMapSQLiteType(t *testing.T) {
+	cases := []struct {
+		declaredType string
+		name         string
+		format       string
+	}{
+		{"Int", "integer", ""},
+		{"UNSIGNED BIG INT", "integer", ""},
+		{"VARCHAR(255)", "string", ""},
+		{"REAL", "number", ""},
+		{"DOUBLE", "number", ""},
+		{"numeric", "number", ""},
+		{"BOOLEAN", "boolean", ""},
+		{"datetime", "string", "date-time"},
+		{"TIMESTAMP", "string", "date-time"},
+		{"DATE", "string", "date"},
+		{"TIME", "string", "time"},
+		{"BLOB", "string", ""},
+		{"", "", ""},
+	}
+	for _, c := range cases {
+		fieldType, err := MapSQLiteType(c.declaredType)
+		assert.Nilf(t, err, "mapping `%s` should succeed", c.declaredType)
+		assert.Equalf(t, c.name, fieldType.Name, "the type of `%s` should be `%s`", c.declaredType, c.name)
+		assert.Equalf(t, c.format, fieldType.Format, "the format of `%s` should be `%s`", c.declaredType, c.format)
+	}
+}
+
+func TestMapSQLiteTypeLength(t *testing.T) {
+	fieldType, err := MapSQLiteType("VARCHAR(255)")
+	assert.Nil(t, err, "mapping the type should succeed")
+	assert.Equal(t, 255, fieldType.MaxLength, "the max length should be 255")
+	assert.False(t, fieldType.FixedLength, "varchar should not have a fixed length")
+	fieldType, err = MapSQLiteType("NCHAR(2)")
+	assert.Nil(t, err, "mapping the type should succeed")
+	assert.Equal(t, 2, fieldType.MaxLength, "the max length should be 2")
+	assert.True(t, fieldType.FixedLength, "char should have a fixed length")
+	fieldType, err = MapSQLiteType("DECIMAL(10,2)")
+	assert.Nil(t, err, "mapping the type should succeed")
+	assert.Equal(t, 10, fieldType.Precision, "the precision should be 10")
+	assert.Equal(t, 2, fieldType.Scale, "the scale should be 2")
+	fieldType, err = MapSQLiteType("BLOB")
+	assert.Nil(t, err, "mapping the type should succeed")
+	assert.Equal(t, "base64", fieldType.ContentEncoding, "blobs should be base64 encoded")
+}
+
+func TestParseComments(t *testing.T) {
+	tableSQL := `CREATE TABLE "birds" ( -- Birds seen in the wild
+  id INTEGER PRIMARY KEY, -- The bird id
+  -- The genus of the bird,
+  -- e.g. 'Ara'
+  genus TEXT DEFAULT '--',
+  "common name" TEXT NOT NULL, -- The name used by "birders" -- not scientists
+  [wing span] REAL,
+  species TEXT,
+  -- ignored since constraints are not columns
+  CHECK (length(genus) > 0)
+)`
+	columns := []string{"id", "genus", "common name", "wing span", "species"}
+	tableComment, columnComments := ParseComments(tableSQL, columns)
+	assert.Equal(t, "Birds seen in the wild", tableComment, "the table comment should be parsed")
+	assert.Equal(t, "The bird id", columnComments["id"], "trailing comments should be parsed")
+	assert.Equal(t, "The genus of the bird, e.g. 'Ara'", columnComments["genus"], "leading comments should be joined")
+	assert.Equal(t, `The name used by "birders" -- not scientists`, columnComments["common name"], "quoted names should be matched")
+	assert.Empty(t, columnComments["wing span"], "columns without comments should not be described")
+	assert.Empty(t, columnComments["species"], "comments should not carry over to later columns")
+	assert.Equal(t, 3, len(columnComments), "only commented columns should be described")
+}
+
+func TestParseChecks(t *testing.T) {
+	tableSQL := `CREATE TABLE tickets (
+  status TEXT NOT NULL CHECK(status IN ('open', 'won''t fix', 'closed')),
+  [level] REAL,
+  kind TEXT CHECK (kind NOT IN ('spam')),
+  owner TEXT CHECK (length(owner) > 0),
+  CONSTRAINT level_check CHECK ("LEVEL" in (0.5, 1, -2e3))
+)`
+	columns := []string{"status", "level", "kind", "owner"}
+	checks := ParseChecks(tableSQL, columns)
+	assert.Equal(t, []interface{}{"open", "won't fix", "closed"}, checks["status"], "string values should be unquoted")
+	assert.Equal(t, []interface{}{0.5, int64(1), -2000.0}, checks["level"], "numeric values should be parsed")
+	assert.Equal(t, 2, len(checks), "only IN checks should be parsed")
+}
+
+func TestDescribeTableChecks(t *testing.T) {
+	tableSQL := `
+CREATE TABLE tickets (
+  id INTEGER PRIMARY KEY,
+  status TEXT NOT NULL CHECK (status IN ('open', 'closed')),
+  archived BOOLEAN CHECK (archived IN (0, 1))
+)`
+	conn := makeTestDB(t, tableSQL)
+	defer conn.Close()
+	table, err := DescribeTable(conn, &SQLiteTable{"tickets", tableSQL})
+	assert.Nil(t, err, "describing the table should succeed")
+	assert.Equal(t, []interface{}{"open", "closed"}, table.Fields[1].Type.Enum, "the check should be an enum")
+	assert.Nil(t, table.Fields[2].Type.Enum, "boolean checks should be ignored")
+}
+
+func TestDescribeTableParsedAutoIncrement(t *testing.T) {
+	tableSQL := "CREATE TABLE counters (id int AUTO_INCREMENT NOT NULL, label text)"
+	conn := makeTestDB(t, tableSQL)
+	defer conn.Close()
+	table, err := DescribeTable(conn, &SQLiteTable{"counters", tableSQL})
+	assert.Nil(t, err, "describing the table should succeed")
+	assert.True(t, table.Fields[0].AutoIncrement, "the parsed AUTO_INCREMENT should be used")
+	assert.False(t, table.Fields[1].AutoIncrement, "the field should not be auto-increment")
+}
+
+func TestParseDefault(t *testing.T) {
+	cases := []struct {
+		declaredType string
+		dflt         string
+		value        interface{}
+		expression   string
+	}{
+		{"TEXT", "'n/a'", "n/a", ""},
+		{"TEXT", "''", "", ""},
+		{"INTEGER", "-1", int64(-1), ""},
+		{"REAL", "1.5", 1.5, ""},
+		{"BOOLEAN", "0", false, ""},
+		{"BOOLEAN", "TRUE", true, ""},
+		{"TEXT", "NULL", nil, ""},
+		{"DATETIME", "CURRENT_TIMESTAMP", nil, "CURRENT_TIMESTAMP"},
+		{"TEXT", "(datetime('now'))", nil, "(datetime('now'))"},
+		{"INTEGER", "'abc'", nil, "'abc'"},
+	}
+	for _, c := range cases {
+		fieldType, err := MapSQLiteType(c.declaredType)
+		assert.Nilf(t, err, "mapping `%s` should succeed", c.declaredType)
+		value, expression := ParseDefault(fieldType, sql.NullString{String: c.dflt, Valid: true})
+		assert.Equalf(t, c.value, value, "the default value of %s should be parsed", c.dflt)
+		assert.Equalf(t, c.expression, expression, "the default expression of %s should be kept", c.dflt)
+	}
 }
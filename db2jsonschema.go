@@ -16,14 +16,26 @@ func MakeLookupMap(items []string) map[string]bool {
 }
 
 type Request struct {
-	Driver     string
-	DataSource string
-	Format     string
-	Outdir     string
-	SchemaType string
-	IdTemplate string
-	Includes   []string
-	Excludes   []string
+	Driver      string
+	DataSource  string
+	Format      string
+	Outdir      string
+	SchemaType  string
+	IdTemplate  string
+	Draft       string
+	References  string
+	FixedLength bool
+	Decimal     string
+	Variants    []string
+	Package     string
+	GoNullable  string
+	GoDecimal   string
+	LockFile    string
+	Namespace   string
+	Template    string
+	HTML        bool
+	Includes    []string
+	Excludes    []string
 }
 
 func (r *Request) FilterTables(tables []*schema.Table) []*schema.Table {
@@ -67,11 +79,23 @@ func (r *Request) Perform() error {
 	}
 	filteredTables := r.FilterTables(tables)
 	request := generator.Request{
-		Tables:     filteredTables,
-		Format:     r.Format,
-		Outdir:     r.Outdir,
-		SchemaType: r.SchemaType,
-		IdTemplate: r.IdTemplate,
+		Tables:      filteredTables,
+		Format:      r.Format,
+		Outdir:      r.Outdir,
+		SchemaType:  r.SchemaType,
+		IdTemplate:  r.IdTemplate,
+		Draft:       r.Draft,
+		References:  r.References,
+		FixedLength: r.FixedLength,
+		Decimal:     r.Decimal,
+		Variants:    r.Variants,
+		Package:     r.Package,
+		GoNullable:  r.GoNullable,
+		GoDecimal:   r.GoDecimal,
+		LockFile:    r.LockFile,
+		Namespace:   r.Namespace,
+		Template:    r.Template,
+		HTML:        r.HTML,
 	}
 	log.WithFields(log.Fields{
 		"generatorRequest": request,
@@ -3,8 +3,10 @@ module github.com/tgallant/db2jsonschema
 go 1.16
 
 require (
+	github.com/DATA-DOG/go-sqlmock v1.5.0
 	github.com/alecthomas/participle/v2 v2.0.0-alpha6
 	github.com/go-sql-driver/mysql v1.6.0
+	github.com/lib/pq v1.10.2
 	github.com/mattn/go-sqlite3 v1.14.8
 	github.com/mitchellh/go-homedir v1.1.0
 	github.com/sirupsen/logrus v1.2.0
@@ -0,0 +1,282 @@
+package generator
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"text/template"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/tgallant/db2jsonschema/internal/schema"
+)
+
+const defaultAvroProtocol = "Definitions"
+
+var avroNameRegexp = regexp.MustCompile(`[^A-Za-z0-9_]+`)
+
+type AvroField struct {
+	Name    string          `json:"name"`
+	Type    interface{}     `json:"type"`
+	Doc     string          `json:"doc,omitempty"`
+	Default json.RawMessage `json:"default,omitempty"`
+}
+
+type AvroRecord struct {
+	Type      string       `json:"type"`
+	Name      string       `json:"name"`
+	Namespace string       `json:"namespace,omitempty"`
+	Doc       string       `json:"doc,omitempty"`
+	Fields    []*AvroField `json:"fields"`
+}
+
+type AvroEnum struct {
+	Type    string   `json:"type"`
+	Name    string   `json:"name"`
+	Symbols []string `json:"symbols"`
+}
+
+type AvroArray struct {
+	Type  string      `json:"type"`
+	Items interface{} `json:"items"`
+}
+
+// AvroLogicalType annotates a primitive type with a logical type such as
+// timestamp-millis or decimal.
+type AvroLogicalType struct {
+	Type        string `json:"type"`
+	LogicalType string `json:"logicalType"`
+	Precision   int    `json:"precision,omitempty"`
+	Scale       int    `json:"scale,omitempty"`
+}
+
+type AvroProtocol struct {
+	Protocol  string                 `json:"protocol"`
+	Namespace string                 `json:"namespace,omitempty"`
+	Types     []*AvroRecord          `json:"types"`
+	Messages  map[string]interface{} `json:"messages"`
+}
+
+// AvroName converts a table or column name into a valid Avro name.
+func AvroName(name string) string {
+	name = avroNameRegexp.ReplaceAllString(name, "_")
+	if len(name) == 0 || (name[0] >= '0' && name[0] <= '9') {
+		name = "_" + name
+	}
+	return name
+}
+
+// FormatNamespaceTemplate returns the Avro namespace of a schema. The
+// template receives the same options as the $id template.
+func (r *Request) FormatNamespaceTemplate(name string) (string, error) {
+	if len(r.Namespace) == 0 {
+		return "", nil
+	}
+	opts := &IdTemplateOptions{
+		Name:   name,
+		Format: r.GetFormat(),
+	}
+	namespaceTemplate, err := template.New("namespaceTemplate").Parse(r.Namespace)
+	if err != nil {
+		return "", err
+	}
+	var namespace bytes.Buffer
+	err = namespaceTemplate.Execute(&namespace, opts)
+	if err != nil {
+		return "", err
+	}
+	return namespace.String(), nil
+}
+
+func (r *Request) avroDecimal(precision int, scale int) interface{} {
+	if r.Decimal == DecimalString {
+		return "string"
+	}
+	return &AvroLogicalType{
+		Type:        "bytes",
+		LogicalType: "decimal",
+		Precision:   precision,
+		Scale:       scale,
+	}
+}
+
+// AvroType returns the Avro type of the values of a property, without
+// null. Enum columns declare an enum named after the record and column,
+// numbering values that map to the same symbol.
+func (r *Request) AvroType(record string, name string, prop *schema.Property) interface{} {
+	if len(prop.Enum) > 0 {
+		var symbols []string
+		var seen = make(map[string]bool)
+		for _, v := range prop.Enum {
+			if v == nil {
+				continue
+			}
+			symbol := AvroName(fmt.Sprint(v))
+			for i := 2; seen[symbol]; i++ {
+				symbol = fmt.Sprintf("%s_%d", AvroName(fmt.Sprint(v)), i)
+			}
+			seen[symbol] = true
+			symbols = append(symbols, symbol)
+		}
+		return &AvroEnum{
+			Type:    "enum",
+			Name:    record + PascalCase(name),
+			Symbols: symbols,
+		}
+	}
+	switch prop.Type {
+	case "string":
+		if len(prop.ContentEncoding) > 0 || prop.Format == "byte" {
+			return "bytes"
+		}
+		switch prop.Format {
+		case "date-time":
+			return &AvroLogicalType{Type: "long", LogicalType: "timestamp-millis"}
+		case "date":
+			return &AvroLogicalType{Type: "int", LogicalType: "date"}
+		case "uuid":
+			return &AvroLogicalType{Type: "string", LogicalType: "uuid"}
+		}
+		return "string"
+	case "integer":
+		if prop.Format == "int32" {
+			return "int"
+		}
+		if ExceedsInt64(prop) {
+			// Unsigned 64 bit integers have up to 20 digits.
+			return r.avroDecimal(20, 0)
+		}
+		return "long"
+	case "number":
+		if prop.Precision > 0 {
+			return r.avroDecimal(prop.Precision, prop.Scale)
+		}
+		return "double"
+	case "boolean":
+		return "boolean"
+	case "array":
+		items := &schema.Property{}
+		if prop.Items != nil {
+			items = prop.Items
+		}
+		return &AvroArray{Type: "array", Items: r.AvroType(record, name, items)}
+	default:
+		// JSON columns are serialized as text.
+		return "string"
+	}
+}
+
+// MakeAvroRecord converts a table variant into an Avro record. Nullable
+// columns are unions with null which default to null.
+func (r *Request) MakeAvroRecord(t *schema.TableProperties) (*AvroRecord, error) {
+	namespace, err := r.FormatNamespaceTemplate(t.Name)
+	if err != nil {
+		return nil, err
+	}
+	record := &AvroRecord{
+		Type:      "record",
+		Name:      AvroName(PascalCase(t.Name)),
+		Namespace: namespace,
+		Doc:       t.Description,
+		Fields:    []*AvroField{},
+	}
+	var names []string
+	for name := range t.Properties {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		prop := t.Properties[name]
+		field := &AvroField{
+			Name: AvroName(name),
+			Type: r.AvroType(record.Name, name, prop),
+			Doc:  prop.Description,
+		}
+		if prop.Nullable {
+			field.Type = []interface{}{"null", field.Type}
+			field.Default = json.RawMessage("null")
+		}
+		record.Fields = append(record.Fields, field)
+	}
+	return record, nil
+}
+
+func (r *Request) MakeAvroRecords(tables []*schema.TableProperties) ([]*AvroRecord, error) {
+	expanded, err := r.ExpandVariants(tables)
+	if err != nil {
+		return nil, err
+	}
+	var records []*AvroRecord
+	for _, t := range expanded {
+		record, err := r.MakeAvroRecord(t)
+		if err != nil {
+			return nil, err
+		}
+		records = append(records, record)
+	}
+	return records, nil
+}
+
+// MakeAvroProtocol combines the records of every table in a protocol.
+func (r *Request) MakeAvroProtocol(tables []*schema.TableProperties) (*AvroProtocol, error) {
+	records, err := r.MakeAvroRecords(tables)
+	if err != nil {
+		return nil, err
+	}
+	namespace, err := r.FormatNamespaceTemplate("definitions")
+	if err != nil {
+		return nil, err
+	}
+	protocol := &AvroProtocol{
+		Protocol:  defaultAvroProtocol,
+		Namespace: namespace,
+		Types:     records,
+		Messages:  map[string]interface{}{},
+	}
+	return protocol, nil
+}
+
+// HandleAvroOutput writes a protocol combining every record to the standard
+// output, or a schema per table to <table>.avsc in the output directory.
+func (r *Request) HandleAvroOutput(tables []*schema.TableProperties) error {
+	if len(r.Outdir) == 0 {
+		protocol, err := r.MakeAvroProtocol(tables)
+		if err != nil {
+			return err
+		}
+		res, err := FormatJSON(protocol)
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(res))
+		return nil
+	}
+	err := os.MkdirAll(r.Outdir, os.ModePerm)
+	if err != nil {
+		return err
+	}
+	expanded, err := r.ExpandVariants(tables)
+	if err != nil {
+		return err
+	}
+	for _, t := range expanded {
+		record, err := r.MakeAvroRecord(t)
+		if err != nil {
+			return err
+		}
+		res, err := FormatJSON(record)
+		if err != nil {
+			return err
+		}
+		outputPath := filepath.Join(r.Outdir, fmt.Sprintf("%s.avsc", t.Name))
+		log.Infof("Writing to %s", outputPath)
+		err = os.WriteFile(outputPath, res, 0666)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
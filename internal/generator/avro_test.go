@@ -0,0 +1,62 @@
+package generator
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/tgallant/db2jsonschema/internal/schema"
+)
+
+func TestAvroType(t *testing.T) {
+	r := &Request{}
+	timestamp := r.AvroType("Birds", "seen_at", &schema.Property{Type: "string", Format: "date-time"})
+	assert.Equal(t, &AvroLogicalType{Type: "long", LogicalType: "timestamp-millis"}, timestamp, "date-time should be timestamp-millis")
+	decimal := r.AvroType("Birds", "wingspan", &schema.Property{Type: "number", Precision: 5, Scale: 2})
+	assert.Equal(t, &AvroLogicalType{Type: "bytes", LogicalType: "decimal", Precision: 5, Scale: 2}, decimal, "decimals should use the decimal logical type")
+	enum := r.AvroType("Birds", "size", &schema.Property{Type: "string", Enum: []interface{}{"small", "extra large"}})
+	assert.Equal(t, &AvroEnum{Type: "enum", Name: "BirdsSize", Symbols: []string{"small", "extra_large"}}, enum, "enum columns should be enums")
+	enum = r.AvroType("Birds", "stock", &schema.Property{Type: "string", Enum: []interface{}{"in-stock", "in stock", "sold"}})
+	assert.Equal(t, []string{"in_stock", "in_stock_2", "sold"}, enum.(*AvroEnum).Symbols, "colliding symbols should be numbered")
+	assert.Equal(t, "long", r.AvroType("Birds", "id", &schema.Property{Type: "integer"}), "integers should be longs")
+	unsigned := r.AvroType("Birds", "id", schema.MakeProperty(&schema.FieldType{Name: "integer", Size: 64, Unsigned: true}))
+	assert.Equal(t, &AvroLogicalType{Type: "bytes", LogicalType: "decimal", Precision: 20, Scale: 0}, unsigned, "unsigned bigints should be decimals")
+	r.Decimal = DecimalString
+	assert.Equal(t, "string", r.AvroType("Birds", "id", schema.MakeProperty(&schema.FieldType{Name: "integer", Size: 64, Unsigned: true})), "unsigned bigints should be strings with --decimal string")
+}
+
+func TestMakeAvroRecord(t *testing.T) {
+	r := &Request{Namespace: "dbserver.music.{{ .Name }}"}
+	record, err := r.MakeAvroRecord(makeRelatedDbTables()[1])
+	assert.Nil(t, err, "creating the record should succeed")
+	assert.Equal(t, "Tracks", record.Name, "the record should be named after the table")
+	assert.Equal(t, "dbserver.music.tracks", record.Namespace, "the namespace template should be used")
+	assert.Equal(t, "genre_id", record.Fields[1].Name, "the fields should be sorted")
+	assert.Equal(t, []interface{}{"null", "double"}, record.Fields[1].Type, "nullable columns should be unions with null")
+	assert.Equal(t, "null", string(record.Fields[1].Default), "nullable columns should default to null")
+	assert.Nil(t, record.Fields[0].Default, "columns that are not nullable should not have a default")
+}
+
+func TestMakeAvroProtocol(t *testing.T) {
+	r := &Request{}
+	protocol, err := r.MakeAvroProtocol(makeRelatedDbTables())
+	assert.Nil(t, err, "creating the protocol should succeed")
+	assert.Equal(t, "Definitions", protocol.Protocol, "the protocol should be named")
+	assert.Empty(t, protocol.Namespace, "the namespace should be omitted without a template")
+	assert.Equal(t, 2, len(protocol.Types), "every table should be a type")
+}
+
+func TestHandleAvroOutput(t *testing.T) {
+	outdir := t.TempDir()
+	r := &Request{
+		Tables: []*schema.Table{makeDbTable()},
+		Format: "avro",
+		Outdir: outdir,
+	}
+	err := r.Perform()
+	assert.Nil(t, err, "performing the request should succeed")
+	res, err := os.ReadFile(filepath.Join(outdir, "Testing.avsc"))
+	assert.Nil(t, err, "each table should be written to its own schema")
+	assert.Contains(t, string(res), "\"type\": \"record\"", "the schema should be a record")
+}
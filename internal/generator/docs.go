@@ -0,0 +1,303 @@
+package generator
+
+import (
+	"bytes"
+	"fmt"
+	htmltemplate "html/template"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"text/template"
+
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	docsMarkdown = "md"
+	docsHTML     = "html"
+	docsIndex    = "index"
+	docsTables   = "tables"
+)
+
+var docsFileNameRegexp = regexp.MustCompile(`[^A-Za-z0-9_.-]+`)
+
+const docsMarkdownPage = `# {{ .Table.Name }}
+{{ if .Table.Comment }}
+{{ .Table.Comment }}
+{{ end }}
+[Index]({{ .Index }})
+
+## Columns
+
+| Column | SQL type | JSON type | Nullable | Default | Comment |
+| --- | --- | --- | --- | --- | --- |
+{{ range .Table.Fields -}}
+| {{ cell .Name }} | {{ cell .SQLType }} | {{ cell (docsJSONType .) }} | {{ yesNo .Nullable }} | {{ cell (docsDefault .) }} | {{ cell .Comment }} |
+{{ end -}}
+{{ if or .Table.PrimaryKeys .Table.UniqueKeys }}
+## Keys
+{{ if .Table.PrimaryKeys }}
+- Primary key: {{ code .Table.PrimaryKeys }}
+{{- end }}
+{{- range .Table.UniqueKeys }}
+- Unique: {{ code . }}
+{{- end }}
+{{ end -}}
+{{ if .Table.ForeignKeys }}
+## Foreign keys
+
+| Column | References |
+| --- | --- |
+{{ range $fk := .Table.ForeignKeys -}}
+| {{ cell $fk.Field }} | {{ with $.Link $fk.ReferencedTable }}[{{ cell $fk.ReferencedTable }}.{{ cell $fk.ReferencedField }}]({{ . }}){{ else }}{{ cell $fk.ReferencedTable }}.{{ cell $fk.ReferencedField }}{{ end }} |
+{{ end -}}
+{{ end -}}
+{{ if .Table.ReferencedBy }}
+## Referenced by
+
+| Table | Column |
+| --- | --- |
+{{ range $fk := .Table.ReferencedBy -}}
+| [{{ cell $fk.Table }}]({{ $.Link $fk.Table }}) | {{ cell $fk.Field }} |
+{{ end -}}
+{{ end -}}
+`
+
+const docsMarkdownIndex = `# Data dictionary
+
+| Table | Columns | Comment |
+| --- | --- | --- |
+{{ range .Tables -}}
+| [{{ cell .Name }}]({{ $.Link .Name }}) | {{ len .Fields }} | {{ cell .Comment }} |
+{{ end -}}
+`
+
+const docsHTMLPage = `<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>{{ .Table.Name }}</title>
+</head>
+<body>
+<h1>{{ .Table.Name }}</h1>
+{{ if .Table.Comment }}<p>{{ .Table.Comment }}</p>
+{{ end }}<p><a href="{{ .Index }}">Index</a></p>
+<h2>Columns</h2>
+<table>
+<tr><th>Column</th><th>SQL type</th><th>JSON type</th><th>Nullable</th><th>Default</th><th>Comment</th></tr>
+{{ range .Table.Fields }}<tr><td>{{ .Name }}</td><td>{{ .SQLType }}</td><td>{{ docsJSONType . }}</td><td>{{ yesNo .Nullable }}</td><td>{{ docsDefault . }}</td><td>{{ .Comment }}</td></tr>
+{{ end }}</table>
+{{ if or .Table.PrimaryKeys .Table.UniqueKeys }}<h2>Keys</h2>
+<ul>
+{{ if .Table.PrimaryKeys }}<li>Primary key: <code>{{ join .Table.PrimaryKeys ", " }}</code></li>
+{{ end }}{{ range .Table.UniqueKeys }}<li>Unique: <code>{{ join . ", " }}</code></li>
+{{ end }}</ul>
+{{ end }}{{ if .Table.ForeignKeys }}<h2>Foreign keys</h2>
+<table>
+<tr><th>Column</th><th>References</th></tr>
+{{ range $fk := .Table.ForeignKeys }}<tr><td>{{ $fk.Field }}</td><td>{{ with $.Link $fk.ReferencedTable }}<a href="{{ . }}">{{ $fk.ReferencedTable }}.{{ $fk.ReferencedField }}</a>{{ else }}{{ $fk.ReferencedTable }}.{{ $fk.ReferencedField }}{{ end }}</td></tr>
+{{ end }}</table>
+{{ end }}{{ if .Table.ReferencedBy }}<h2>Referenced by</h2>
+<table>
+<tr><th>Table</th><th>Column</th></tr>
+{{ range $fk := .Table.ReferencedBy }}<tr><td><a href="{{ $.Link $fk.Table }}">{{ $fk.Table }}</a></td><td>{{ $fk.Field }}</td></tr>
+{{ end }}</table>
+{{ end }}</body>
+</html>
+`
+
+const docsHTMLIndex = `<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>Data dictionary</title>
+</head>
+<body>
+<h1>Data dictionary</h1>
+<table>
+<tr><th>Table</th><th>Columns</th><th>Comment</th></tr>
+{{ range .Tables }}<tr><td><a href="{{ $.Link .Name }}">{{ .Name }}</a></td><td>{{ len .Fields }}</td><td>{{ .Comment }}</td></tr>
+{{ end }}</table>
+</body>
+</html>
+`
+
+// DocsPage is the data of the page of a table, or of the index when Table
+// is nil. Ext is the extension of the pages it links to and Dir the
+// directory of the table pages relative to the page.
+type DocsPage struct {
+	Tables []*TemplateTable
+	Table  *TemplateTable
+	Ext    string
+	Dir    string
+	Index  string
+}
+
+// Link returns the link to the page of a table, or an empty string when the
+// table is not documented.
+func (p *DocsPage) Link(table string) string {
+	for _, t := range p.Tables {
+		if t.Name == table {
+			return p.Dir + DocsFileName(table) + "." + p.Ext
+		}
+	}
+	return ""
+}
+
+// DocsFileName converts a table name into the name of its page, without
+// the extension. Separators are replaced so that pages stay in their
+// directory and the name needs no escaping in links.
+func DocsFileName(table string) string {
+	return docsFileNameRegexp.ReplaceAllString(table, "_")
+}
+
+// docsJSONType describes the JSON type of a column, e.g. string (date-time).
+func docsJSONType(field *TemplateField) string {
+	name := JSONType(field)
+	if len(name) == 0 {
+		return "any"
+	}
+	if name == "array" && field.Type.Items != nil && len(field.Type.Items.Name) > 0 {
+		return fmt.Sprintf("array (%s)", field.Type.Items.Name)
+	}
+	if len(field.Type.Format) > 0 {
+		return fmt.Sprintf("%s (%s)", name, field.Type.Format)
+	}
+	return name
+}
+
+func docsDefault(field *TemplateField) string {
+	if len(field.DefaultExpression) > 0 {
+		return field.DefaultExpression
+	}
+	if field.Default == nil {
+		return ""
+	}
+	res, err := toJSON(field.Default)
+	if err != nil {
+		return fmt.Sprint(field.Default)
+	}
+	return res
+}
+
+func yesNo(value bool) string {
+	if value {
+		return "yes"
+	}
+	return "no"
+}
+
+// markdownCell escapes text for a cell of a Markdown table.
+func markdownCell(text string) string {
+	text = strings.ReplaceAll(text, "|", "\\|")
+	return strings.ReplaceAll(text, "\n", "<br>")
+}
+
+// markdownCode lists column names as Markdown code spans.
+func markdownCode(names []string) string {
+	var spans []string
+	for _, name := range names {
+		spans = append(spans, fmt.Sprintf("`%s`", name))
+	}
+	return strings.Join(spans, ", ")
+}
+
+var docsFuncs = map[string]interface{}{
+	"docsJSONType": docsJSONType,
+	"docsDefault":  docsDefault,
+	"yesNo":        yesNo,
+	"cell":         markdownCell,
+	"code":         markdownCode,
+	"join":         strings.Join,
+}
+
+// ExecuteDocs executes the Markdown or HTML template of a page.
+func ExecuteDocs(text string, page *DocsPage) (string, error) {
+	var res bytes.Buffer
+	if page.Ext == docsHTML {
+		t, err := htmltemplate.New(docsHTML).Funcs(docsFuncs).Parse(text)
+		if err != nil {
+			return "", err
+		}
+		err = t.Execute(&res, page)
+		return res.String(), err
+	}
+	t, err := template.New(docsMarkdown).Funcs(docsFuncs).Parse(text)
+	if err != nil {
+		return "", err
+	}
+	err = t.Execute(&res, page)
+	return res.String(), err
+}
+
+// MakeDocs renders the index and the page of every table with the given
+// extension, keyed by file name.
+func (r *Request) MakeDocs(ext string) (map[string]string, []string, error) {
+	model := MakeTemplateModel(r.Tables)
+	pageTemplate, indexTemplate := docsMarkdownPage, docsMarkdownIndex
+	if ext == docsHTML {
+		pageTemplate, indexTemplate = docsHTMLPage, docsHTMLIndex
+	}
+	index := fmt.Sprintf("%s.%s", docsIndex, ext)
+	var pages = make(map[string]string)
+	var names []string
+	res, err := ExecuteDocs(indexTemplate, &DocsPage{Tables: model.Tables, Ext: ext, Dir: docsTables + "/", Index: index})
+	if err != nil {
+		return nil, nil, err
+	}
+	pages[index] = res
+	names = append(names, index)
+	for _, table := range model.Tables {
+		page := &DocsPage{Tables: model.Tables, Table: table, Ext: ext, Index: "../" + index}
+		res, err := ExecuteDocs(pageTemplate, page)
+		if err != nil {
+			return nil, nil, err
+		}
+		// Table pages have their own directory so that a table named
+		// index does not overwrite the index.
+		name := fmt.Sprintf("%s/%s.%s", docsTables, DocsFileName(table.Name), ext)
+		pages[name] = res
+		names = append(names, name)
+	}
+	return pages, names, nil
+}
+
+// HandleDocsOutput writes the data dictionary, a Markdown page per table
+// and an index, to the output directory along with HTML pages when
+// requested. Without an output directory the Markdown pages are printed.
+func (r *Request) HandleDocsOutput() error {
+	exts := []string{docsMarkdown}
+	if r.HTML {
+		exts = append(exts, docsHTML)
+	}
+	if len(r.Outdir) > 0 {
+		err := os.MkdirAll(filepath.Join(r.Outdir, docsTables), os.ModePerm)
+		if err != nil {
+			return err
+		}
+	}
+	for _, ext := range exts {
+		pages, names, err := r.MakeDocs(ext)
+		if err != nil {
+			return err
+		}
+		if len(r.Outdir) == 0 {
+			if ext == docsMarkdown {
+				for _, name := range names {
+					fmt.Println(pages[name])
+				}
+			}
+			continue
+		}
+		for _, name := range names {
+			outputPath := filepath.Join(r.Outdir, filepath.FromSlash(name))
+			log.Infof("Writing to %s", outputPath)
+			err = os.WriteFile(outputPath, []byte(pages[name]), 0666)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,73 @@
+package generator
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/tgallant/db2jsonschema/internal/schema"
+)
+
+func TestMakeDocs(t *testing.T) {
+	tables := makeRelatedTables()
+	tables[0].Comment = "Released albums"
+	tables[0].Fields[0].SQLType = "bigint"
+	tables[0].Fields[0].Type = &schema.FieldType{Name: "integer", Size: 64}
+	tables[0].Fields = append(tables[0].Fields, &schema.Field{Name: "title", Type: &schema.FieldType{Name: "string"}, Nullable: true, Default: "Untitled"})
+	r := &Request{Tables: tables}
+	pages, names, err := r.MakeDocs("md")
+	assert.Nil(t, err, "creating the docs should succeed")
+	assert.Equal(t, []string{"index.md", "tables/albums.md", "tables/tracks.md"}, names, "there should be an index and a page per table")
+	assert.Contains(t, pages["index.md"], "| [albums](tables/albums.md) | 2 | Released albums |\n", "the index should link to every table")
+	albums := pages["tables/albums.md"]
+	assert.Contains(t, albums, "[Index](../index.md)\n", "the pages should link to the index")
+	assert.Contains(t, albums, "| id | bigint | integer | no |  |  |\n", "the columns should be listed")
+	assert.Contains(t, albums, "| title |  | string | yes | \"Untitled\" |  |\n", "the defaults should be listed")
+	assert.Contains(t, albums, "- Primary key: `id`\n", "the keys should be listed")
+	assert.Contains(t, albums, "| [tracks](tracks.md) | album_id |\n", "incoming foreign keys should be linked")
+	tracks := pages["tables/tracks.md"]
+	assert.Contains(t, tracks, "| album_id | [albums.id](albums.md) |\n", "outgoing foreign keys should be linked")
+	assert.Contains(t, tracks, "| genre_id | genres.id |\n", "tables that are not documented should not be linked")
+}
+
+func TestMakeDocsTableNamedIndex(t *testing.T) {
+	r := &Request{Tables: []*schema.Table{{Name: "index", Fields: []*schema.Field{{Name: "id", Type: &schema.FieldType{Name: "integer"}}}}}}
+	pages, names, err := r.MakeDocs("md")
+	assert.Nil(t, err, "creating the docs should succeed")
+	assert.Equal(t, []string{"index.md", "tables/index.md"}, names, "the page of the table should not replace the index")
+	assert.Contains(t, pages["index.md"], "# Data dictionary\n", "the index should be kept")
+}
+
+func TestMakeDocsEscapingTableName(t *testing.T) {
+	r := &Request{Tables: []*schema.Table{{Name: "../albums", Fields: []*schema.Field{{Name: "id", Type: &schema.FieldType{Name: "integer"}}}}}}
+	pages, names, err := r.MakeDocs("md")
+	assert.Nil(t, err, "creating the docs should succeed")
+	assert.Equal(t, []string{"index.md", "tables/.._albums.md"}, names, "the page should stay in the tables directory")
+	assert.Contains(t, pages["index.md"], "| [../albums](tables/.._albums.md) |", "the link should match the page")
+}
+
+func TestDocsPageLink(t *testing.T) {
+	page := &DocsPage{Tables: []*TemplateTable{{Name: "order items"}}, Ext: "html"}
+	assert.Equal(t, "order_items.html", page.Link("order items"), "links should use the file name")
+	assert.Empty(t, page.Link("missing"), "tables that are not documented should not be linked")
+}
+
+func TestHandleDocsOutput(t *testing.T) {
+	outdir := t.TempDir()
+	r := &Request{
+		Tables: makeRelatedTables(),
+		Format: "docs",
+		Outdir: outdir,
+		HTML:   true,
+	}
+	err := r.Perform()
+	assert.Nil(t, err, "performing the request should succeed")
+	for _, name := range []string{"index.md", "tables/albums.md", "tables/tracks.md", "index.html", "tables/albums.html", "tables/tracks.html"} {
+		_, err := os.Stat(filepath.Join(outdir, name))
+		assert.Nilf(t, err, "%s should be written", name)
+	}
+	res, err := os.ReadFile(filepath.Join(outdir, "tables", "tracks.html"))
+	assert.Nil(t, err, "the HTML page should be written")
+	assert.Contains(t, string(res), "<a href=\"albums.html\">albums.id</a>", "the HTML pages should link to each other")
+}
@@ -0,0 +1,155 @@
+package generator
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/tgallant/db2jsonschema/internal/schema"
+)
+
+// Draft describes the keywords used by a JSON Schema dialect.
+type Draft struct {
+	Name string
+	URI  string
+	// LegacyId uses `id` instead of `$id` (draft-04).
+	LegacyId bool
+	// Defs uses `$defs` instead of `definitions` (2019-09 and later).
+	Defs bool
+	// NullableKeyword uses `nullable: true` instead of a type array.
+	NullableKeyword bool
+	// BooleanExclusive treats exclusiveMinimum and exclusiveMaximum as
+	// boolean modifiers of minimum and maximum (draft-04).
+	BooleanExclusive bool
+	// Content supports contentEncoding (draft-07 and later).
+	Content bool
+	// ReadOnly supports readOnly (draft-07 and later, OpenAPI).
+	ReadOnly bool
+	// ByteFormat describes base64 content with `format: byte` (OpenAPI).
+	ByteFormat bool
+	// OpenAPI is the version of the OpenAPI documents using the draft.
+	OpenAPI string
+}
+
+const defaultDraft = "2020-12"
+
+var drafts = []*Draft{
+	{
+		Name:             "draft-04",
+		URI:              "http://json-schema.org/draft-04/schema#",
+		LegacyId:         true,
+		BooleanExclusive: true,
+	},
+	{
+		Name: "draft-06",
+		URI:  "http://json-schema.org/draft-06/schema#",
+	},
+	{
+		Name:     "draft-07",
+		URI:      "http://json-schema.org/draft-07/schema#",
+		Content:  true,
+		ReadOnly: true,
+	},
+	{
+		Name:     "2019-09",
+		URI:      "https://json-schema.org/draft/2019-09/schema",
+		Defs:     true,
+		Content:  true,
+		ReadOnly: true,
+	},
+	{
+		Name:     "2020-12",
+		URI:      "https://json-schema.org/draft/2020-12/schema",
+		Defs:     true,
+		Content:  true,
+		ReadOnly: true,
+	},
+	{
+		// Schema Objects of OpenAPI 3.0 do not allow $schema.
+		Name:             "openapi-3.0",
+		NullableKeyword:  true,
+		BooleanExclusive: true,
+		ByteFormat:       true,
+		ReadOnly:         true,
+		OpenAPI:          "3.0.3",
+	},
+	{
+		Name:     "openapi-3.1",
+		URI:      "https://spec.openapis.org/oas/3.1/dialect/base",
+		Defs:     true,
+		Content:  true,
+		ReadOnly: true,
+		OpenAPI:  "3.1.0",
+	},
+}
+
+func normalizeSchemaURI(uri string) string {
+	uri = strings.TrimSuffix(uri, "#")
+	uri = strings.TrimPrefix(uri, "http://")
+	uri = strings.TrimPrefix(uri, "https://")
+	return uri
+}
+
+func LookupDraft(name string) (*Draft, error) {
+	for _, d := range drafts {
+		if d.Name == name {
+			return d, nil
+		}
+	}
+	return nil, fmt.Errorf("Unknown draft: %s", name)
+}
+
+// LookupDraftByURI finds the draft identified by a $schema URI.
+func LookupDraftByURI(uri string) (*Draft, bool) {
+	for _, d := range drafts {
+		if len(d.URI) > 0 && normalizeSchemaURI(d.URI) == normalizeSchemaURI(uri) {
+			return d, true
+		}
+	}
+	return nil, false
+}
+
+// DefinitionsPointer returns the JSON pointer of a definition within a
+// definitions document.
+func (d *Draft) DefinitionsPointer(name string) string {
+	if d.Defs {
+		return fmt.Sprintf("#/$defs/%s", name)
+	}
+	return fmt.Sprintf("#/definitions/%s", name)
+}
+
+func (d *Draft) SetExclusiveMinimum(p *schema.Property, value interface{}) {
+	if d.BooleanExclusive {
+		p.Minimum = value
+		p.ExclusiveMinimum = true
+		return
+	}
+	p.ExclusiveMinimum = value
+}
+
+func (d *Draft) SetExclusiveMaximum(p *schema.Property, value interface{}) {
+	if d.BooleanExclusive {
+		p.Maximum = value
+		p.ExclusiveMaximum = true
+		return
+	}
+	p.ExclusiveMaximum = value
+}
+
+// FormatKeywords rewrites keywords the draft does not define into their
+// closest equivalent, or drops them when there is none.
+func (d *Draft) FormatKeywords(p *schema.Property) *schema.Property {
+	formatted := *p
+	if len(p.ContentEncoding) > 0 && !d.Content {
+		formatted.ContentEncoding = ""
+		if d.ByteFormat && p.ContentEncoding == "base64" {
+			formatted.Format = "byte"
+		}
+	}
+	if !d.ReadOnly {
+		formatted.ReadOnly = false
+	}
+	if p.Items != nil {
+		formatted.Items = d.FormatKeywords(p.Items)
+	}
+	return &formatted
+}
@@ -0,0 +1,74 @@
+package generator
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/tgallant/db2jsonschema/internal/schema"
+)
+
+func TestLookupDraftByURI(t *testing.T) {
+	d, exists := LookupDraftByURI("https://json-schema.org/draft-07/schema")
+	assert.True(t, exists, "the draft should be found")
+	assert.Equal(t, "draft-07", d.Name, "the draft should be draft-07")
+	_, exists = LookupDraftByURI("https://example.com/schema")
+	assert.False(t, exists, "an unknown URI should not match a draft")
+	_, exists = LookupDraftByURI("")
+	assert.False(t, exists, "an empty URI should not match a draft")
+}
+
+func TestDefinitionsPointer(t *testing.T) {
+	d, err := LookupDraft("2019-09")
+	assert.Nil(t, err, "looking up the draft should succeed")
+	assert.Equal(t, "#/$defs/albums", d.DefinitionsPointer("albums"), "2019-09 should use $defs")
+	d, err = LookupDraft("draft-06")
+	assert.Nil(t, err, "looking up the draft should succeed")
+	assert.Equal(t, "#/definitions/albums", d.DefinitionsPointer("albums"), "draft-06 should use definitions")
+}
+
+func TestSetExclusiveMinimum(t *testing.T) {
+	d, err := LookupDraft("draft-04")
+	assert.Nil(t, err, "looking up the draft should succeed")
+	p := &schema.Property{}
+	d.SetExclusiveMinimum(p, 0)
+	assert.Equal(t, 0, p.Minimum, "draft-04 should set minimum")
+	assert.Equal(t, true, p.ExclusiveMinimum, "draft-04 should use a boolean exclusiveMinimum")
+	d, err = LookupDraft("draft-07")
+	assert.Nil(t, err, "looking up the draft should succeed")
+	p = &schema.Property{}
+	d.SetExclusiveMinimum(p, 0)
+	assert.Nil(t, p.Minimum, "draft-07 should not set minimum")
+	assert.Equal(t, 0, p.ExclusiveMinimum, "draft-07 should use a numeric exclusiveMinimum")
+}
+
+func TestFormatKeywords(t *testing.T) {
+	p := &schema.Property{
+		Type:            "string",
+		ContentEncoding: "base64",
+	}
+	d, err := LookupDraft("draft-07")
+	assert.Nil(t, err, "looking up the draft should succeed")
+	assert.Equal(t, "base64", d.FormatKeywords(p).ContentEncoding, "draft-07 should keep contentEncoding")
+	d, err = LookupDraft("draft-06")
+	assert.Nil(t, err, "looking up the draft should succeed")
+	assert.Empty(t, d.FormatKeywords(p).ContentEncoding, "draft-06 should drop contentEncoding")
+	d, err = LookupDraft("openapi-3.0")
+	assert.Nil(t, err, "looking up the draft should succeed")
+	formatted := d.FormatKeywords(p)
+	assert.Empty(t, formatted.ContentEncoding, "openapi-3.0 should drop contentEncoding")
+	assert.Equal(t, "byte", formatted.Format, "openapi-3.0 should use the byte format")
+}
+
+func TestFormatKeywordsReadOnly(t *testing.T) {
+	p := &schema.Property{
+		Type:     "integer",
+		ReadOnly: true,
+	}
+	d, err := LookupDraft("draft-07")
+	assert.Nil(t, err, "looking up the draft should succeed")
+	assert.True(t, d.FormatKeywords(p).ReadOnly, "draft-07 should keep readOnly")
+	d, err = LookupDraft("draft-04")
+	assert.Nil(t, err, "looking up the draft should succeed")
+	assert.False(t, d.FormatKeywords(p).ReadOnly, "draft-04 should drop readOnly")
+	assert.True(t, p.ReadOnly, "the property should not be modified")
+}
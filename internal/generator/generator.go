@@ -4,8 +4,10 @@ import (
 	"bytes"
 	"encoding/json"
 	"fmt"
+	"math"
 	"os"
 	"path/filepath"
+	"strings"
 	"text/template"
 
 	log "github.com/sirupsen/logrus"
@@ -29,6 +31,16 @@ func FormatYAML(schema interface{}) ([]byte, error) {
 	return res, nil
 }
 
+const (
+	ReferencesAnnotate = "annotate"
+	ReferencesExpand   = "expand"
+)
+
+const (
+	DecimalNumber = "number"
+	DecimalString = "string"
+)
+
 const (
 	defaultFormat     = "json"
 	defaultSchemaType = "https://json-schema.org/draft/2020-12/schema"
@@ -41,6 +53,30 @@ type Request struct {
 	Outdir     string
 	SchemaType string
 	IdTemplate string
+	Draft      string
+	References string
+	// FixedLength sets minLength to the maxLength of fixed length types
+	// such as CHAR(n).
+	FixedLength bool
+	Variants    []string
+	// Decimal selects how DECIMAL(p,s) columns are represented, either as
+	// a number with bounds and multipleOf or as a string with a pattern.
+	Decimal string
+	// Package, GoNullable and GoDecimal configure the go format: the name
+	// of the package, whether nullable columns are pointers or sql.Null*
+	// types and the type of DECIMAL(p,s) columns.
+	Package    string
+	GoNullable string
+	GoDecimal  string
+	// LockFile persists the field numbers of the proto format.
+	LockFile string
+	// Namespace is a template string for the namespace of Avro schemas.
+	Namespace string
+	// Template is a template file, or a directory of them, executed
+	// instead of generating schemas.
+	Template string
+	// HTML writes the docs format as HTML pages too.
+	HTML bool
 }
 
 func (r *Request) GetFormat() string {
@@ -50,10 +86,25 @@ func (r *Request) GetFormat() string {
 	return defaultFormat
 }
 
+// GetDraft returns the draft named by the request. When no draft is given it
+// is inferred from the schema type, falling back to the default draft.
+func (r *Request) GetDraft() (*Draft, error) {
+	if len(r.Draft) > 0 {
+		return LookupDraft(r.Draft)
+	}
+	if d, exists := LookupDraftByURI(r.SchemaType); exists {
+		return d, nil
+	}
+	return LookupDraft(defaultDraft)
+}
+
 func (r *Request) GetSchemaType() string {
 	if len(r.SchemaType) > 0 {
 		return r.SchemaType
 	}
+	if d, err := r.GetDraft(); err == nil {
+		return d.URI
+	}
 	return defaultSchemaType
 }
 
@@ -86,39 +137,255 @@ func (r *Request) FormatIdTemplate(name string) (string, error) {
 	return idValue.String(), nil
 }
 
-func (r *Request) MakeDefinitionsDoc(tables []*schema.TableProperties) (*schema.DefinitionsDocument, error) {
-	var definitions = make(map[string]map[string]*schema.JSONProperty)
+// FormatNullable encodes the nullability of a property. JSON Schema
+// expresses it as a type array while OpenAPI uses the nullable keyword.
+// Either way an enum has to list null for it to be accepted.
+func (r *Request) FormatNullable(d *Draft, prop *schema.Property) *schema.Property {
+	if !prop.Nullable {
+		return prop
+	}
+	formatted := *prop
+	if len(prop.Enum) > 0 {
+		formatted.Enum = append(append([]interface{}{}, prop.Enum...), nil)
+	}
+	if d.NullableKeyword {
+		return &formatted
+	}
+	formatted.Nullable = false
+	if name, ok := prop.Type.(string); ok && len(name) > 0 {
+		formatted.Type = []string{name, "null"}
+	}
+	return &formatted
+}
+
+// MakeReferences maps each table onto the URI used to reference the schema
+// of its rows, as given by pointer. Definitions documents use local
+// pointers, otherwise the $id of the table's own schema file is used. Tables
+// are not referenced when the read variant is not generated.
+func (r *Request) MakeReferences(tables []*schema.TableProperties, pointer func(string) (string, error)) (map[string]string, error) {
+	var refs = make(map[string]string)
+	switch r.GetReferences() {
+	case "":
+		return refs, nil
+	case ReferencesAnnotate, ReferencesExpand:
+	default:
+		return nil, fmt.Errorf("Unknown references: %s", r.References)
+	}
+	variants, err := r.GetVariants()
+	if err != nil {
+		return nil, err
+	}
+	if !hasVariant(variants, VariantRead) {
+		return refs, nil
+	}
 	for _, t := range tables {
-		props := schema.MakePropertiesMap(t.Properties)
-		definitions[t.Name] = props
+		ref, err := pointer(VariantName(t.Name, VariantRead))
+		if err != nil {
+			return nil, err
+		}
+		refs[t.Name] = ref
+	}
+	return refs, nil
+}
+
+// GetReferences returns how foreign keys reference other schemas. OpenAPI
+// documents reference their components by default.
+func (r *Request) GetReferences() string {
+	if len(r.References) == 0 && r.IsOpenAPI() {
+		return ReferencesExpand
+	}
+	return r.References
+}
+
+func (r *Request) FormatForeignKey(d *Draft, prop *schema.Property, fk *schema.ForeignKey, ref string) *schema.Property {
+	switch r.GetReferences() {
+	case ReferencesAnnotate:
+		formatted := *prop
+		pointer := fmt.Sprintf("#/properties/%s", fk.ReferencedField)
+		if strings.HasPrefix(ref, "#") {
+			pointer = fmt.Sprintf("/properties/%s", fk.ReferencedField)
+		}
+		formatted.References = ref + pointer
+		return &formatted
+	case ReferencesExpand:
+		if !prop.Nullable {
+			return &schema.Property{Ref: ref}
+		}
+		expanded := &schema.Property{
+			AnyOf: []*schema.Property{{Ref: ref}},
+		}
+		if d.NullableKeyword {
+			expanded.Nullable = true
+		} else {
+			expanded.AnyOf = append(expanded.AnyOf, &schema.Property{Type: "null"})
+		}
+		return expanded
+	default:
+		return prop
+	}
+}
+
+func (r *Request) FormatFixedLength(prop *schema.Property) *schema.Property {
+	if !r.FixedLength || !prop.FixedLength || prop.MaxLength == 0 {
+		return prop
+	}
+	formatted := *prop
+	formatted.MinLength = prop.MaxLength
+	return &formatted
+}
+
+// FormatDecimal constrains a DECIMAL(p,s) property to the values the column
+// can store.
+func (r *Request) FormatDecimal(d *Draft, prop *schema.Property) *schema.Property {
+	if prop.Precision == 0 {
+		return prop
+	}
+	formatted := *prop
+	digits := prop.Precision - prop.Scale
+	if r.Decimal == DecimalString {
+		formatted.Type = "string"
+		formatted.Minimum = nil
+		formatted.Pattern = DecimalPattern(digits, prop.Scale, prop.Minimum == nil)
+		if prop.Default != nil {
+			formatted.Default = fmt.Sprint(prop.Default)
+		}
+		return &formatted
+	}
+	bound := math.Pow10(digits)
+	if prop.Minimum == nil {
+		d.SetExclusiveMinimum(&formatted, -bound)
+	}
+	d.SetExclusiveMaximum(&formatted, bound)
+	formatted.MultipleOf = math.Pow10(-prop.Scale)
+	return &formatted
+}
+
+// DecimalPattern matches the text of a decimal with at most digits integer
+// digits and scale fractional digits.
+func DecimalPattern(digits int, scale int, signed bool) string {
+	var pattern strings.Builder
+	pattern.WriteString("^")
+	if signed {
+		pattern.WriteString("-?")
+	}
+	if digits > 0 {
+		pattern.WriteString(fmt.Sprintf("\\d{1,%d}", digits))
+	} else {
+		pattern.WriteString("0")
+	}
+	if scale > 0 {
+		pattern.WriteString(fmt.Sprintf("(\\.\\d{1,%d})?", scale))
+	}
+	pattern.WriteString("$")
+	return pattern.String()
+}
+
+// ExceedsInt64 reports whether an integer property holds values above the
+// maximum of an int64, as unsigned 64 bit columns do.
+func ExceedsInt64(prop *schema.Property) bool {
+	maximum, ok := prop.Maximum.(uint64)
+	return ok && maximum > math.MaxInt64
+}
+
+func (r *Request) MakeProperties(d *Draft, t *schema.TableProperties, refs map[string]string) map[string]*schema.Property {
+	var foreignKeys = make(map[string]*schema.ForeignKey)
+	for _, fk := range t.ForeignKeys {
+		foreignKeys[fk.Field] = fk
+	}
+	var props = make(map[string]*schema.Property)
+	for name, p := range t.Properties {
+		p = r.FormatDecimal(d, r.FormatFixedLength(p))
+		if fk, exists := foreignKeys[name]; exists {
+			if ref, exists := refs[fk.ReferencedTable]; exists {
+				p = r.FormatForeignKey(d, p, fk, ref)
+			}
+		}
+		props[name] = r.FormatNullable(d, d.FormatKeywords(p))
+	}
+	return props
+}
+
+func (r *Request) MakeDefinitionsDoc(tables []*schema.TableProperties) (*schema.DefinitionsDocument, error) {
+	d, err := r.GetDraft()
+	if err != nil {
+		return &schema.DefinitionsDocument{}, err
+	}
+	refs, err := r.MakeReferences(tables, func(name string) (string, error) {
+		return d.DefinitionsPointer(name), nil
+	})
+	if err != nil {
+		return &schema.DefinitionsDocument{}, err
+	}
+	expanded, err := r.ExpandVariants(tables)
+	if err != nil {
+		return &schema.DefinitionsDocument{}, err
+	}
+	var definitions = make(map[string]*schema.JSONDefinition)
+	for _, t := range expanded {
+		definitions[t.Name] = &schema.JSONDefinition{
+			Description: t.Description,
+			Type:        "object",
+			Properties:  r.MakeProperties(d, t, refs),
+			Required:    t.Required,
+			PrimaryKey:  t.PrimaryKeys,
+			UniqueKeys:  t.UniqueKeys,
+		}
 	}
 	schemaId, err := r.FormatIdTemplate("definitions")
 	if err != nil {
 		return &schema.DefinitionsDocument{}, err
 	}
 	doc := &schema.DefinitionsDocument{
-		Schema:      r.GetSchemaType(),
-		Id:          schemaId,
-		Title:       "Definitions",
-		Definitions: definitions,
+		Schema: r.GetSchemaType(),
+		Title:  "Definitions",
+	}
+	if d.LegacyId {
+		doc.LegacyId = schemaId
+	} else {
+		doc.Id = schemaId
+	}
+	if d.Defs {
+		doc.Defs = definitions
+	} else {
+		doc.Definitions = definitions
 	}
 	return doc, nil
 }
 
 func (r *Request) MakeSchema(tables []*schema.TableProperties) ([]*schema.JSONSchema, error) {
+	d, err := r.GetDraft()
+	if err != nil {
+		return []*schema.JSONSchema{}, err
+	}
+	refs, err := r.MakeReferences(tables, r.FormatIdTemplate)
+	if err != nil {
+		return []*schema.JSONSchema{}, err
+	}
+	expanded, err := r.ExpandVariants(tables)
+	if err != nil {
+		return []*schema.JSONSchema{}, err
+	}
 	var jsonSchemas []*schema.JSONSchema
-	for _, t := range tables {
-		properties := schema.MakePropertiesMap(t.Properties)
+	for _, t := range expanded {
+		properties := r.MakeProperties(d, t, refs)
 		schemaId, err := r.FormatIdTemplate(t.Name)
 		if err != nil {
 			return []*schema.JSONSchema{}, err
 		}
 		jsonSchema := &schema.JSONSchema{
-			Schema:     r.GetSchemaType(),
-			Id:         schemaId,
-			Title:      t.Name,
-			Type:       "object",
-			Properties: properties,
+			Schema:      r.GetSchemaType(),
+			Title:       t.Name,
+			Description: t.Description,
+			Type:        "object",
+			Properties:  properties,
+			Required:    t.Required,
+			PrimaryKey:  t.PrimaryKeys,
+			UniqueKeys:  t.UniqueKeys,
+		}
+		if d.LegacyId {
+			jsonSchema.LegacyId = schemaId
+		} else {
+			jsonSchema.Id = schemaId
 		}
 		jsonSchemas = append(jsonSchemas, jsonSchema)
 	}
@@ -130,15 +397,22 @@ func (r *Request) FormatSchema(schema interface{}) ([]byte, error) {
 	switch format {
 	case "json":
 		return FormatJSON(schema)
-	case "yaml":
+	case "yaml", "openapi":
 		return FormatYAML(schema)
 	default:
 		return nil, fmt.Errorf("Unknown format: %s", format)
 	}
 }
 
+func (r *Request) MakeDocument(tables []*schema.TableProperties) (interface{}, error) {
+	if r.IsOpenAPI() {
+		return r.MakeOpenAPIDoc(tables)
+	}
+	return r.MakeDefinitionsDoc(tables)
+}
+
 func (r *Request) HandleStandardOutput(tables []*schema.TableProperties) error {
-	doc, err := r.MakeDefinitionsDoc(tables)
+	doc, err := r.MakeDocument(tables)
 	if err != nil {
 		return err
 	}
@@ -155,6 +429,9 @@ func (r *Request) HandleDirectoryOutput(tables []*schema.TableProperties) error
 	if err != nil {
 		return err
 	}
+	if r.IsOpenAPI() {
+		return r.HandleOpenAPIOutput(tables)
+	}
 	schema, err := r.MakeSchema(tables)
 	if err != nil {
 		return err
@@ -175,12 +452,45 @@ func (r *Request) HandleDirectoryOutput(tables []*schema.TableProperties) error
 	return nil
 }
 
+// HandleOpenAPIOutput writes a single OpenAPI document, as the components of
+// a document reference each other.
+func (r *Request) HandleOpenAPIOutput(tables []*schema.TableProperties) error {
+	doc, err := r.MakeOpenAPIDoc(tables)
+	if err != nil {
+		return err
+	}
+	res, err := r.FormatSchema(doc)
+	if err != nil {
+		return err
+	}
+	outputPath := filepath.Join(r.Outdir, "openapi.yaml")
+	log.Infof("Writing to %s", outputPath)
+	return os.WriteFile(outputPath, res, 0666)
+}
+
 func (r *Request) Perform() error {
+	if len(r.Template) > 0 {
+		return r.HandleTemplateOutput()
+	}
 	var tables []*schema.TableProperties
 	for _, table := range r.Tables {
 		properties := schema.MakeTableProperties(table)
 		tables = append(tables, properties)
 	}
+	switch r.GetFormat() {
+	case "typescript":
+		return r.HandleTypeScriptOutput(tables)
+	case "go":
+		return r.HandleGoOutput(tables)
+	case "proto":
+		return r.HandleProtoOutput(tables)
+	case "avro":
+		return r.HandleAvroOutput(tables)
+	case "graphql":
+		return r.HandleGraphQLOutput(tables)
+	case "docs":
+		return r.HandleDocsOutput()
+	}
 	if len(r.Outdir) > 0 {
 		return r.HandleDirectoryOutput(tables)
 	}
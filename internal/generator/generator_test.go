@@ -38,10 +38,10 @@ func TestMakeDefinitionsDoc(t *testing.T) {
 	r := &Request{}
 	doc, err := r.MakeDefinitionsDoc(props)
 	assert.Nilf(t, err, "creating the definitions doc for %s should succeed", table.Name)
-	assert.Equal(t, 1, len(doc.Definitions), "should have 1 definition")
-	def := doc.Definitions["Testing"]
-	assert.Equal(t, 2, len(def), "should have 2 properties")
-	property := def["UserId"]
+	assert.Equal(t, 1, len(doc.Defs), "should have 1 definition")
+	def := doc.Defs["Testing"]
+	assert.Equal(t, 2, len(def.Properties), "should have 2 properties")
+	property := def.Properties["UserId"]
 	assert.Equal(t, "number", property.Type, "type should be `number`")
 }
 
@@ -57,10 +57,10 @@ func TestMakeDefinitionsDocWithIdTemplate(t *testing.T) {
 	doc, err := r.MakeDefinitionsDoc(props)
 	assert.Nilf(t, err, "creating the definitions doc for %s should succeed", table.Name)
 	assert.Equalf(t, idValue, doc.Id, "the $id value should be %s", idValue)
-	assert.Equal(t, 1, len(doc.Definitions), "should have 1 definition")
-	def := doc.Definitions["Testing"]
-	assert.Equal(t, 2, len(def), "should have 2 properties")
-	property := def["UserId"]
+	assert.Equal(t, 1, len(doc.Defs), "should have 1 definition")
+	def := doc.Defs["Testing"]
+	assert.Equal(t, 2, len(def.Properties), "should have 2 properties")
+	property := def.Properties["UserId"]
 	assert.Equal(t, "number", property.Type, "type should be `number`")
 }
 
@@ -76,13 +76,114 @@ func TestMakeDefinitionsDocWithSchemaType(t *testing.T) {
 	doc, err := r.MakeDefinitionsDoc(props)
 	assert.Nilf(t, err, "creating the definitions doc for %s should succeed", table.Name)
 	assert.Equalf(t, schemaValue, doc.Schema, "the $schema value should be %s", schemaValue)
-	assert.Equal(t, 1, len(doc.Definitions), "should have 1 definition")
-	def := doc.Definitions["Testing"]
-	assert.Equal(t, 2, len(def), "should have 2 properties")
-	property := def["UserId"]
+	assert.Equal(t, 1, len(doc.Defs), "should have 1 definition")
+	def := doc.Defs["Testing"]
+	assert.Equal(t, 2, len(def.Properties), "should have 2 properties")
+	property := def.Properties["UserId"]
 	assert.Equal(t, "number", property.Type, "type should be `number`")
 }
 
+func makeNullableDbTable() *schema.Table {
+	table := makeDbTable()
+	table.Fields[0].Nullable = true
+	return table
+}
+
+func TestMakeDefinitionsDocRequired(t *testing.T) {
+	var props []*schema.TableProperties
+	table := makeNullableDbTable()
+	p := schema.MakeTableProperties(table)
+	props = append(props, p)
+	r := &Request{}
+	doc, err := r.MakeDefinitionsDoc(props)
+	assert.Nilf(t, err, "creating the definitions doc for %s should succeed", table.Name)
+	def := doc.Defs["Testing"]
+	assert.Equal(t, []string{"UserId"}, def.Required, "only `UserId` should be required")
+	property := def.Properties["exampleField"]
+	assert.Equal(t, []string{"string", "null"}, property.Type, "type should include `null`")
+	assert.False(t, property.Nullable, "the nullable keyword should not be set")
+}
+
+func makeRelatedTables() []*schema.Table {
+	albums := &schema.Table{
+		Name:        "albums",
+		PrimaryKeys: []string{"id"},
+		Fields: []*schema.Field{
+			{Name: "id", Type: &schema.FieldType{Name: "number"}},
+		},
+	}
+	tracks := &schema.Table{
+		Name: "tracks",
+		Fields: []*schema.Field{
+			{Name: "id", Type: &schema.FieldType{Name: "number"}},
+			{Name: "album_id", Type: &schema.FieldType{Name: "number"}},
+			{Name: "genre_id", Type: &schema.FieldType{Name: "number"}, Nullable: true},
+		},
+		ForeignKeys: []*schema.ForeignKey{
+			{Field: "album_id", ReferencedTable: "albums", ReferencedField: "id"},
+			{Field: "genre_id", ReferencedTable: "genres", ReferencedField: "id"},
+		},
+	}
+	return []*schema.Table{albums, tracks}
+}
+
+func makeRelatedDbTables() []*schema.TableProperties {
+	var props []*schema.TableProperties
+	for _, table := range makeRelatedTables() {
+		props = append(props, schema.MakeTableProperties(table))
+	}
+	return props
+}
+
+func TestMakeSchemaReferencesAnnotate(t *testing.T) {
+	r := &Request{
+		IdTemplate: "https://example.com/{{ .Name }}.json",
+		References: ReferencesAnnotate,
+	}
+	schemas, err := r.MakeSchema(makeRelatedDbTables())
+	assert.Nil(t, err, "creating the schemas should succeed")
+	property := schemas[1].Properties["album_id"]
+	assert.Equal(t, "number", property.Type, "type should be `number`")
+	assert.Equal(t, "https://example.com/albums.json#/properties/id", property.References, "the reference should use the $id")
+	property = schemas[1].Properties["genre_id"]
+	assert.Empty(t, property.References, "tables that are not generated should not be referenced")
+}
+
+func TestMakeSchemaReferencesExpand(t *testing.T) {
+	r := &Request{
+		References: ReferencesExpand,
+	}
+	schemas, err := r.MakeSchema(makeRelatedDbTables())
+	assert.Nil(t, err, "creating the schemas should succeed")
+	property := schemas[1].Properties["album_id"]
+	assert.Nil(t, property.Type, "type should not be set")
+	assert.Equal(t, "albums.json", property.Ref, "the $ref should use the $id")
+}
+
+func TestMakeDefinitionsDocReferencesExpand(t *testing.T) {
+	r := &Request{
+		References: ReferencesExpand,
+	}
+	tables := makeRelatedDbTables()
+	tables[1].Properties["album_id"].Nullable = true
+	doc, err := r.MakeDefinitionsDoc(tables)
+	assert.Nil(t, err, "creating the definitions doc should succeed")
+	property := doc.Defs["tracks"].Properties["album_id"]
+	assert.Equal(t, 2, len(property.AnyOf), "a nullable reference should allow null")
+	assert.Equal(t, "#/$defs/albums", property.AnyOf[0].Ref, "the $ref should point into the document")
+	assert.Equal(t, "null", property.AnyOf[1].Type, "a nullable reference should allow null")
+}
+
+func TestMakeSchemaReferencesUnknown(t *testing.T) {
+	r := &Request{
+		References: "inline",
+	}
+	_, err := r.MakeSchema(makeRelatedDbTables())
+	assert.NotNil(t, err, "an unknown references mode should fail")
+	_, err = r.MakeDefinitionsDoc(makeRelatedDbTables())
+	assert.NotNil(t, err, "an unknown references mode should fail")
+}
+
 func TestPerform(t *testing.T) {
 	var tables []*schema.Table
 	table := makeDbTable()
@@ -95,3 +196,201 @@ func TestPerform(t *testing.T) {
 	err := request.Perform()
 	assert.Nil(t, err, "performing the request should succeed")
 }
+
+func TestMakeDefinitionsDocWithDraft(t *testing.T) {
+	var props []*schema.TableProperties
+	table := makeDbTable()
+	p := schema.MakeTableProperties(table)
+	props = append(props, p)
+	r := &Request{
+		Draft: "draft-07",
+	}
+	doc, err := r.MakeDefinitionsDoc(props)
+	assert.Nilf(t, err, "creating the definitions doc for %s should succeed", table.Name)
+	assert.Equal(t, "http://json-schema.org/draft-07/schema#", doc.Schema, "the $schema value should be draft-07")
+	assert.Equal(t, "definitions.json", doc.Id, "the $id value should be set")
+	assert.Empty(t, doc.Defs, "$defs should not be used")
+	assert.Equal(t, 1, len(doc.Definitions), "should have 1 definition")
+}
+
+func TestMakeDefinitionsDocWithDraftFromSchemaType(t *testing.T) {
+	var props []*schema.TableProperties
+	table := makeDbTable()
+	p := schema.MakeTableProperties(table)
+	props = append(props, p)
+	r := &Request{
+		SchemaType: "http://json-schema.org/draft-04/schema",
+	}
+	doc, err := r.MakeDefinitionsDoc(props)
+	assert.Nilf(t, err, "creating the definitions doc for %s should succeed", table.Name)
+	assert.Equal(t, "http://json-schema.org/draft-04/schema", doc.Schema, "the $schema value should be kept")
+	assert.Empty(t, doc.Id, "$id should not be used")
+	assert.Equal(t, "definitions.json", doc.LegacyId, "the id value should be set")
+	assert.Equal(t, 1, len(doc.Definitions), "should have 1 definition")
+}
+
+func TestMakeSchemaWithUnknownDraft(t *testing.T) {
+	var props []*schema.TableProperties
+	table := makeDbTable()
+	p := schema.MakeTableProperties(table)
+	props = append(props, p)
+	r := &Request{
+		Draft: "draft-99",
+	}
+	_, err := r.MakeSchema(props)
+	assert.NotNil(t, err, "an unknown draft should fail")
+}
+
+func TestMakeSchemaNullableOpenAPIDraft(t *testing.T) {
+	var props []*schema.TableProperties
+	table := makeNullableDbTable()
+	p := schema.MakeTableProperties(table)
+	props = append(props, p)
+	r := &Request{
+		Draft: "openapi-3.0",
+	}
+	schemas, err := r.MakeSchema(props)
+	assert.Nilf(t, err, "creating the schema for %s should succeed", table.Name)
+	property := schemas[0].Properties["exampleField"]
+	assert.Equal(t, "string", property.Type, "type should be `string`")
+	assert.True(t, property.Nullable, "the nullable keyword should be set")
+}
+
+func TestMakeSchemaOpenAPIDraftOmitsSchema(t *testing.T) {
+	props := []*schema.TableProperties{schema.MakeTableProperties(makeDbTable())}
+	r := &Request{Draft: "openapi-3.0"}
+	schemas, err := r.MakeSchema(props)
+	assert.Nil(t, err, "creating the schema should succeed")
+	assert.Empty(t, schemas[0].Schema, "OpenAPI 3.0 schemas should not have a $schema")
+	res, err := r.FormatSchema(schemas[0])
+	assert.Nil(t, err, "formatting the schema should succeed")
+	assert.NotContains(t, string(res), "$schema", "the $schema keyword should be omitted")
+	doc, err := r.MakeDefinitionsDoc(props)
+	assert.Nil(t, err, "creating the definitions doc should succeed")
+	assert.Empty(t, doc.Schema, "OpenAPI 3.0 documents should not have a $schema")
+	r = &Request{Draft: "openapi-3.1"}
+	schemas, err = r.MakeSchema(props)
+	assert.Nil(t, err, "creating the schema should succeed")
+	assert.Equal(t, "https://spec.openapis.org/oas/3.1/dialect/base", schemas[0].Schema, "OpenAPI 3.1 schemas should use the dialect")
+}
+
+func TestMakeSchemaDescriptions(t *testing.T) {
+	var props []*schema.TableProperties
+	table := makeDbTable()
+	table.Comment = "A table for testing"
+	table.Fields[1].Comment = "The id of the user"
+	p := schema.MakeTableProperties(table)
+	props = append(props, p)
+	r := &Request{}
+	schemas, err := r.MakeSchema(props)
+	assert.Nilf(t, err, "creating the schema for %s should succeed", table.Name)
+	assert.Equal(t, "A table for testing", schemas[0].Description, "the table comment should be the description")
+	property := schemas[0].Properties["UserId"]
+	assert.Equal(t, "The id of the user", property.Description, "the column comment should be the description")
+	doc, err := r.MakeDefinitionsDoc(props)
+	assert.Nilf(t, err, "creating the definitions doc for %s should succeed", table.Name)
+	assert.Equal(t, "A table for testing", doc.Defs["Testing"].Description, "the table comment should be the description")
+}
+
+func TestMakeSchemaNullableEnum(t *testing.T) {
+	table := makeNullableDbTable()
+	table.Fields[0].Type.Enum = []interface{}{"a", "b"}
+	props := []*schema.TableProperties{schema.MakeTableProperties(table)}
+	r := &Request{}
+	schemas, err := r.MakeSchema(props)
+	assert.Nil(t, err, "creating the schema should succeed")
+	property := schemas[0].Properties[table.Fields[0].Name]
+	assert.Equal(t, []interface{}{"a", "b", nil}, property.Enum, "nullable enums should allow null")
+	r = &Request{Draft: "openapi-3.0"}
+	schemas, err = r.MakeSchema(props)
+	assert.Nil(t, err, "creating the schema should succeed")
+	property = schemas[0].Properties[table.Fields[0].Name]
+	assert.Equal(t, []interface{}{"a", "b", nil}, property.Enum, "nullable enums should allow null")
+	assert.Equal(t, []interface{}{"a", "b"}, table.Fields[0].Type.Enum, "the field type should not be modified")
+}
+
+func TestMakeSchemaFixedLength(t *testing.T) {
+	table := makeDbTable()
+	table.Fields[0].Type.MaxLength = 3
+	table.Fields[0].Type.FixedLength = true
+	props := []*schema.TableProperties{schema.MakeTableProperties(table)}
+	r := &Request{}
+	schemas, err := r.MakeSchema(props)
+	assert.Nil(t, err, "creating the schema should succeed")
+	property := schemas[0].Properties["exampleField"]
+	assert.Equal(t, 3, property.MaxLength, "the max length should be 3")
+	assert.Equal(t, 0, property.MinLength, "the min length should not be set by default")
+	r = &Request{FixedLength: true}
+	schemas, err = r.MakeSchema(props)
+	assert.Nil(t, err, "creating the schema should succeed")
+	property = schemas[0].Properties["exampleField"]
+	assert.Equal(t, 3, property.MinLength, "the min length should equal the max length")
+}
+
+func TestMakeSchemaDecimalNumber(t *testing.T) {
+	table := makeDbTable()
+	table.Fields[1].Type = &schema.FieldType{Name: "number", Precision: 10, Scale: 2}
+	props := []*schema.TableProperties{schema.MakeTableProperties(table)}
+	r := &Request{}
+	schemas, err := r.MakeSchema(props)
+	assert.Nil(t, err, "creating the schema should succeed")
+	property := schemas[0].Properties["UserId"]
+	assert.Equal(t, "number", property.Type, "the type should be `number`")
+	assert.Equal(t, -1e8, property.ExclusiveMinimum, "the minimum should follow from the precision")
+	assert.Equal(t, 1e8, property.ExclusiveMaximum, "the maximum should follow from the precision")
+	assert.Equal(t, 0.01, property.MultipleOf, "multipleOf should follow from the scale")
+	r = &Request{Draft: "draft-04"}
+	schemas, err = r.MakeSchema(props)
+	assert.Nil(t, err, "creating the schema should succeed")
+	property = schemas[0].Properties["UserId"]
+	assert.Equal(t, 1e8, property.Maximum, "draft-04 should use maximum")
+	assert.Equal(t, true, property.ExclusiveMaximum, "draft-04 should use a boolean exclusiveMaximum")
+}
+
+func TestMakeSchemaDecimalString(t *testing.T) {
+	table := makeDbTable()
+	table.Fields[1].Type = &schema.FieldType{Name: "number", Precision: 10, Scale: 2}
+	table.Fields[1].Default = 9.5
+	props := []*schema.TableProperties{schema.MakeTableProperties(table)}
+	r := &Request{Decimal: DecimalString}
+	schemas, err := r.MakeSchema(props)
+	assert.Nil(t, err, "creating the schema should succeed")
+	property := schemas[0].Properties["UserId"]
+	assert.Equal(t, "string", property.Type, "the type should be `string`")
+	assert.Equal(t, `^-?\d{1,8}(\.\d{1,2})?$`, property.Pattern, "the pattern should follow from precision and scale")
+	assert.Equal(t, "9.5", property.Default, "the default should be a string")
+	assert.Nil(t, property.MultipleOf, "multipleOf should not be set")
+}
+
+func TestDecimalPattern(t *testing.T) {
+	assert.Equal(t, `^\d{1,5}$`, DecimalPattern(5, 0, false), "integers should not have a fraction")
+	assert.Equal(t, `^-?0(\.\d{1,3})?$`, DecimalPattern(0, 3, true), "fractions should have a leading zero")
+}
+
+func TestMakeSchemaKeys(t *testing.T) {
+	table := makeDbTable()
+	table.PrimaryKeys = []string{"UserId"}
+	table.UniqueKeys = [][]string{{"exampleField"}}
+	props := []*schema.TableProperties{schema.MakeTableProperties(table)}
+	r := &Request{}
+	schemas, err := r.MakeSchema(props)
+	assert.Nil(t, err, "creating the schema should succeed")
+	assert.Equal(t, []string{"UserId"}, schemas[0].PrimaryKey, "the primary key should be listed")
+	assert.Equal(t, [][]string{{"exampleField"}}, schemas[0].UniqueKeys, "the unique keys should be listed")
+}
+
+func TestMakeSchemaReadOnly(t *testing.T) {
+	table := makeDbTable()
+	table.Fields[1].AutoIncrement = true
+	props := []*schema.TableProperties{schema.MakeTableProperties(table)}
+	r := &Request{}
+	schemas, err := r.MakeSchema(props)
+	assert.Nil(t, err, "creating the schema should succeed")
+	assert.True(t, schemas[0].Properties["UserId"].ReadOnly, "auto-increment columns should be readOnly")
+	assert.False(t, schemas[0].Properties["exampleField"].ReadOnly, "other columns should not be readOnly")
+	assert.Equal(t, []string{"exampleField", "UserId"}, schemas[0].Required, "readOnly columns should be required")
+	r = &Request{Variants: []string{VariantInput}}
+	doc, err := r.MakeDefinitionsDoc(props)
+	assert.Nil(t, err, "creating the definitions doc should succeed")
+	assert.Equal(t, []string{"exampleField"}, doc.Defs["Testing.input"].Required, "the input variant should not require readOnly columns")
+}
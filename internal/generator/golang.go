@@ -0,0 +1,308 @@
+package generator
+
+import (
+	"fmt"
+	"go/format"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"unicode"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/tgallant/db2jsonschema/internal/schema"
+)
+
+const (
+	GoNullablePointer = "pointer"
+	GoNullableSQL     = "sql"
+)
+
+const (
+	defaultGoPackage  = "models"
+	goGeneratedHeader = "// Code generated by db2jsonschema. DO NOT EDIT.\n"
+)
+
+var goInitialisms = map[string]bool{
+	"API":  true,
+	"HTML": true,
+	"HTTP": true,
+	"ID":   true,
+	"IP":   true,
+	"JSON": true,
+	"SQL":  true,
+	"URI":  true,
+	"URL":  true,
+	"UUID": true,
+}
+
+// sqlNullTypes maps Go types onto the database/sql type able to hold them
+// or NULL.
+var sqlNullTypes = map[string]string{
+	"string":    "sql.NullString",
+	"int32":     "sql.NullInt32",
+	"int64":     "sql.NullInt64",
+	"float64":   "sql.NullFloat64",
+	"bool":      "sql.NullBool",
+	"time.Time": "sql.NullTime",
+}
+
+// GoType is a Go type along with the import paths it needs.
+type GoType struct {
+	Name    string
+	Imports []string
+}
+
+// GoStruct is the declaration of the struct of a table variant.
+type GoStruct struct {
+	Name    string
+	Struct  string
+	Imports []string
+	Source  string
+}
+
+func (r *Request) GetPackage() string {
+	if len(r.Package) > 0 {
+		return r.Package
+	}
+	return defaultGoPackage
+}
+
+func (r *Request) GetGoNullable() (string, error) {
+	switch r.GoNullable {
+	case "", GoNullablePointer:
+		return GoNullablePointer, nil
+	case GoNullableSQL:
+		return GoNullableSQL, nil
+	default:
+		return "", fmt.Errorf("Unknown Go nullable type: %s", r.GoNullable)
+	}
+}
+
+// GoName converts a table or column name into an exported Go identifier,
+// keeping common initialisms such as ID upper case.
+func GoName(name string) string {
+	var b strings.Builder
+	for _, word := range SplitWords(name) {
+		if upper := strings.ToUpper(word); goInitialisms[upper] {
+			b.WriteString(upper)
+			continue
+		}
+		runes := []rune(word)
+		b.WriteRune(unicode.ToUpper(runes[0]))
+		b.WriteString(string(runes[1:]))
+	}
+	res := b.String()
+	if len(res) == 0 || unicode.IsDigit([]rune(res)[0]) {
+		res = "X" + res
+	}
+	return res
+}
+
+// ParseGoType parses a type that may be qualified by its import path, such
+// as github.com/shopspring/decimal.Decimal.
+func ParseGoType(name string) *GoType {
+	slash := strings.LastIndex(name, "/")
+	dot := strings.LastIndex(name, ".")
+	if dot <= slash {
+		return &GoType{Name: name}
+	}
+	path := name[:dot]
+	pkg := path[slash+1:]
+	return &GoType{
+		Name:    fmt.Sprintf("%s.%s", pkg, name[dot+1:]),
+		Imports: []string{path},
+	}
+}
+
+func (r *Request) goDecimalType() *GoType {
+	if len(r.GoDecimal) > 0 {
+		return ParseGoType(r.GoDecimal)
+	}
+	if r.Decimal == DecimalString {
+		return &GoType{Name: "string"}
+	}
+	return &GoType{Name: "float64"}
+}
+
+// GoValueType returns the Go type of the values of a property, without
+// NULL.
+func (r *Request) GoValueType(prop *schema.Property) *GoType {
+	switch prop.Type {
+	case "string":
+		if len(prop.ContentEncoding) > 0 || prop.Format == "byte" {
+			return &GoType{Name: "[]byte"}
+		}
+		if prop.Format == "date" || prop.Format == "date-time" {
+			return &GoType{Name: "time.Time", Imports: []string{"time"}}
+		}
+		return &GoType{Name: "string"}
+	case "integer":
+		if prop.Format == "int32" {
+			return &GoType{Name: "int32"}
+		}
+		if ExceedsInt64(prop) {
+			return &GoType{Name: "uint64"}
+		}
+		return &GoType{Name: "int64"}
+	case "number":
+		if prop.Precision > 0 {
+			return r.goDecimalType()
+		}
+		return &GoType{Name: "float64"}
+	case "boolean":
+		return &GoType{Name: "bool"}
+	case "array":
+		items := &GoType{Name: "interface{}"}
+		if prop.Items != nil {
+			items = r.GoValueType(prop.Items)
+		}
+		return &GoType{Name: "[]" + items.Name, Imports: items.Imports}
+	default:
+		return &GoType{Name: "json.RawMessage", Imports: []string{"encoding/json"}}
+	}
+}
+
+// GoFieldType returns the Go type of a property. Nullable columns map onto
+// pointers or sql.Null* types, except for slices which are nil already.
+func (r *Request) GoFieldType(prop *schema.Property, nullable string) *GoType {
+	t := r.GoValueType(prop)
+	if !prop.Nullable || strings.HasPrefix(t.Name, "[]") || t.Name == "json.RawMessage" {
+		return t
+	}
+	if nullType, exists := sqlNullTypes[t.Name]; exists && nullable == GoNullableSQL {
+		return &GoType{Name: nullType, Imports: []string{"database/sql"}}
+	}
+	return &GoType{Name: "*" + t.Name, Imports: t.Imports}
+}
+
+func writeLineComment(b *strings.Builder, indent string, text string) {
+	for _, line := range strings.Split(text, "\n") {
+		fmt.Fprintf(b, "%s// %s\n", indent, line)
+	}
+}
+
+// MakeGoStruct declares the struct of a table variant with json and db tags.
+func (r *Request) MakeGoStruct(t *schema.TableProperties, nullable string) *GoStruct {
+	var names []string
+	for name := range t.Properties {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	s := &GoStruct{
+		Name:   t.Name,
+		Struct: GoName(t.Name),
+	}
+	var imports = make(map[string]bool)
+	var b strings.Builder
+	writeLineComment(&b, "", fmt.Sprintf("%s is generated from the %s schema.", s.Struct, t.Name))
+	if len(t.Description) > 0 {
+		b.WriteString("//\n")
+		writeLineComment(&b, "", t.Description)
+	}
+	fmt.Fprintf(&b, "type %s struct {\n", s.Struct)
+	for _, name := range names {
+		prop := t.Properties[name]
+		fieldType := r.GoFieldType(prop, nullable)
+		for _, path := range fieldType.Imports {
+			imports[path] = true
+		}
+		if len(prop.Description) > 0 {
+			writeLineComment(&b, "\t", prop.Description)
+		}
+		fmt.Fprintf(&b, "\t%s %s `json:\"%s\" db:\"%s\"`\n", GoName(name), fieldType.Name, name, name)
+	}
+	b.WriteString("}\n")
+	for path := range imports {
+		s.Imports = append(s.Imports, path)
+	}
+	sort.Strings(s.Imports)
+	s.Source = b.String()
+	return s
+}
+
+// MakeGoStructs declares the struct of every requested variant of every
+// table.
+func (r *Request) MakeGoStructs(tables []*schema.TableProperties) ([]*GoStruct, error) {
+	nullable, err := r.GetGoNullable()
+	if err != nil {
+		return nil, err
+	}
+	expanded, err := r.ExpandVariants(tables)
+	if err != nil {
+		return nil, err
+	}
+	var structs []*GoStruct
+	for _, t := range expanded {
+		structs = append(structs, r.MakeGoStruct(t, nullable))
+	}
+	return structs, nil
+}
+
+// FormatGoFile returns the gofmt-ed source of a file of the package
+// declaring the given structs.
+func (r *Request) FormatGoFile(structs []*GoStruct) ([]byte, error) {
+	var imports = make(map[string]bool)
+	var sources []string
+	for _, s := range structs {
+		for _, path := range s.Imports {
+			imports[path] = true
+		}
+		sources = append(sources, s.Source)
+	}
+	var b strings.Builder
+	b.WriteString(goGeneratedHeader)
+	fmt.Fprintf(&b, "\npackage %s\n", r.GetPackage())
+	var paths []string
+	for path := range imports {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	if len(paths) > 0 {
+		b.WriteString("\nimport (\n")
+		for _, path := range paths {
+			fmt.Fprintf(&b, "\t%q\n", path)
+		}
+		b.WriteString(")\n")
+	}
+	b.WriteString("\n")
+	b.WriteString(strings.Join(sources, "\n"))
+	return format.Source([]byte(b.String()))
+}
+
+// HandleGoOutput writes every struct to a single file on the standard
+// output, or each struct to its own file of the package in the output
+// directory.
+func (r *Request) HandleGoOutput(tables []*schema.TableProperties) error {
+	structs, err := r.MakeGoStructs(tables)
+	if err != nil {
+		return err
+	}
+	if len(r.Outdir) == 0 {
+		res, err := r.FormatGoFile(structs)
+		if err != nil {
+			return err
+		}
+		fmt.Print(string(res))
+		return nil
+	}
+	err = os.MkdirAll(r.Outdir, os.ModePerm)
+	if err != nil {
+		return err
+	}
+	for _, s := range structs {
+		res, err := r.FormatGoFile([]*GoStruct{s})
+		if err != nil {
+			return err
+		}
+		// The suffix keeps table names such as orders_test or data_arm64 from
+		// reading as test files or build constraints.
+		outputPath := filepath.Join(r.Outdir, fmt.Sprintf("%s_model.go", SnakeCase(s.Name)))
+		log.Infof("Writing to %s", outputPath)
+		err = os.WriteFile(outputPath, res, 0666)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,92 @@
+package generator
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/tgallant/db2jsonschema/internal/schema"
+)
+
+func TestGoName(t *testing.T) {
+	assert.Equal(t, "AlbumID", GoName("album_id"), "initialisms should be upper case")
+	assert.Equal(t, "BirdSightingsCreate", GoName("bird_sightings.create"), "variant names should be exported identifiers")
+	assert.Equal(t, "X2fa", GoName("2fa"), "identifiers should not start with a digit")
+}
+
+func TestParseGoType(t *testing.T) {
+	goType := ParseGoType("github.com/shopspring/decimal.Decimal")
+	assert.Equal(t, "decimal.Decimal", goType.Name, "the type should be qualified by its package")
+	assert.Equal(t, []string{"github.com/shopspring/decimal"}, goType.Imports, "the package should be imported")
+	goType = ParseGoType("string")
+	assert.Nil(t, goType.Imports, "builtin types should not be imported")
+}
+
+func TestGoFieldType(t *testing.T) {
+	r := &Request{}
+	timestamp := &schema.Property{Type: "string", Format: "date-time", Nullable: true}
+	assert.Equal(t, "*time.Time", r.GoFieldType(timestamp, GoNullablePointer).Name, "nullable columns should be pointers")
+	assert.Equal(t, "sql.NullTime", r.GoFieldType(timestamp, GoNullableSQL).Name, "nullable columns should use sql.Null* types")
+	blob := &schema.Property{Type: "string", ContentEncoding: "base64", Nullable: true}
+	assert.Equal(t, "[]byte", r.GoFieldType(blob, GoNullablePointer).Name, "slices should not be pointers")
+	column := schema.MakeProperty(&schema.FieldType{Name: "string", ContentEncoding: "base64"})
+	assert.Equal(t, "[]byte", r.GoFieldType(column, GoNullablePointer).Name, "blob columns should be byte slices")
+	decimal := &schema.Property{Type: "number", Precision: 10, Scale: 2}
+	assert.Equal(t, "float64", r.GoFieldType(decimal, GoNullablePointer).Name, "decimals should default to float64")
+	bigint := schema.MakeProperty(&schema.FieldType{Name: "integer", Size: 64})
+	assert.Equal(t, "int64", r.GoFieldType(bigint, GoNullablePointer).Name, "bigint columns should be int64")
+	unsigned := schema.MakeProperty(&schema.FieldType{Name: "integer", Size: 64, Unsigned: true})
+	assert.Equal(t, "uint64", r.GoFieldType(unsigned, GoNullablePointer).Name, "unsigned bigint columns should be uint64")
+	r.GoDecimal = "github.com/shopspring/decimal.Decimal"
+	assert.Equal(t, "decimal.Decimal", r.GoFieldType(decimal, GoNullablePointer).Name, "decimals should use the configured type")
+}
+
+func TestFormatGoFile(t *testing.T) {
+	r := &Request{Package: "music"}
+	tables := makeRelatedDbTables()
+	structs, err := r.MakeGoStructs(tables)
+	assert.Nil(t, err, "creating the structs should succeed")
+	res, err := r.FormatGoFile(structs)
+	assert.Nil(t, err, "formatting the file should succeed")
+	src := string(res)
+	assert.Contains(t, src, "package music\n", "the package name should be used")
+	assert.Contains(t, src, "type Tracks struct {\n", "each table should be a struct")
+	assert.Contains(t, src, "\tAlbumID float64  `json:\"album_id\" db:\"album_id\"`\n", "fields should have json and db tags")
+	assert.Contains(t, src, "\tGenreID *float64 `json:\"genre_id\" db:\"genre_id\"`\n", "the source should be gofmt-ed")
+}
+
+func TestGetGoNullableUnknown(t *testing.T) {
+	r := &Request{GoNullable: "optional"}
+	_, err := r.MakeGoStructs(makeRelatedDbTables())
+	assert.NotNil(t, err, "an unknown nullable type should fail")
+}
+
+func TestHandleGoOutput(t *testing.T) {
+	outdir := t.TempDir()
+	r := &Request{
+		Tables: []*schema.Table{makeDbTable()},
+		Format: "go",
+		Outdir: outdir,
+	}
+	err := r.Perform()
+	assert.Nil(t, err, "performing the request should succeed")
+	res, err := os.ReadFile(filepath.Join(outdir, "testing_model.go"))
+	assert.Nil(t, err, "each table should be written to its own file")
+	assert.Contains(t, string(res), "package models\n", "the package name should default to models")
+}
+
+func TestHandleGoOutputFileNames(t *testing.T) {
+	outdir := t.TempDir()
+	tables := makeRelatedTables()[:1]
+	tables[0].Name = "orders_test"
+	r := &Request{
+		Tables: tables,
+		Format: "go",
+		Outdir: outdir,
+	}
+	err := r.Perform()
+	assert.Nil(t, err, "performing the request should succeed")
+	_, err = os.Stat(filepath.Join(outdir, "orders_test_model.go"))
+	assert.Nil(t, err, "the file name should not end in _test.go")
+}
@@ -0,0 +1,239 @@
+package generator
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/tgallant/db2jsonschema/internal/schema"
+)
+
+const graphQLOutputFile = "schema.graphql"
+
+var graphQLNameRegexp = regexp.MustCompile(`[^A-Za-z0-9_]+`)
+
+var graphQLForeignKeySuffixRegexp = regexp.MustCompile(`(?:_id|Id|ID|_fk)$`)
+
+// graphQLScalars are the custom scalars in the order they are declared.
+var graphQLScalars = []string{"BigInt", "Date", "DateTime", "Decimal", "JSON"}
+
+// GraphQLName converts a table or column name into a valid GraphQL name.
+func GraphQLName(name string) string {
+	name = graphQLNameRegexp.ReplaceAllString(name, "_")
+	if len(name) == 0 || (name[0] >= '0' && name[0] <= '9') {
+		name = "_" + name
+	}
+	return name
+}
+
+// GraphQLRelationName returns the name of the field of the row referenced by
+// a foreign key column, e.g. album for album_id.
+func GraphQLRelationName(column string, table string, t *schema.TableProperties) string {
+	name := graphQLForeignKeySuffixRegexp.ReplaceAllString(column, "")
+	if _, exists := t.Properties[name]; !exists && len(name) > 0 && name != column {
+		return GraphQLName(name)
+	}
+	return GraphQLName(column + "_" + table)
+}
+
+func writeGraphQLDescription(b *strings.Builder, indent string, text string) {
+	if len(text) == 0 {
+		return
+	}
+	res, err := json.Marshal(text)
+	if err != nil {
+		return
+	}
+	fmt.Fprintf(b, "%s%s\n", indent, res)
+}
+
+type graphQLDocument struct {
+	scalars map[string]bool
+	// declared lists the enums already declared.
+	declared map[string]bool
+	enums    strings.Builder
+	types    strings.Builder
+}
+
+// scalarType returns the GraphQL type of the values of a property, without
+// non-null, declaring the enum of enum columns.
+func (g *graphQLDocument) scalarType(r *Request, enum string, prop *schema.Property) string {
+	if len(prop.Enum) > 0 {
+		g.writeEnum(enum, prop.Enum)
+		return enum
+	}
+	switch prop.Type {
+	case "string":
+		switch prop.Format {
+		case "date-time":
+			g.scalars["DateTime"] = true
+			return "DateTime"
+		case "date":
+			g.scalars["Date"] = true
+			return "Date"
+		}
+		return "String"
+	case "integer":
+		// Int is a signed 32 bit integer.
+		if prop.Format == "int64" || ExceedsInt64(prop) {
+			g.scalars["BigInt"] = true
+			return "BigInt"
+		}
+		return "Int"
+	case "number":
+		if prop.Precision > 0 && r.Decimal != DecimalString {
+			g.scalars["Decimal"] = true
+			return "Decimal"
+		}
+		if prop.Precision > 0 {
+			return "String"
+		}
+		return "Float"
+	case "boolean":
+		return "Boolean"
+	case "array":
+		items := &schema.Property{}
+		if prop.Items != nil {
+			items = prop.Items
+		}
+		return fmt.Sprintf("[%s]", g.scalarType(r, enum, items))
+	default:
+		g.scalars["JSON"] = true
+		return "JSON"
+	}
+}
+
+func (g *graphQLDocument) writeEnum(name string, values []interface{}) {
+	if g.declared[name] {
+		return
+	}
+	g.declared[name] = true
+	var seen = make(map[string]bool)
+	fmt.Fprintf(&g.enums, "enum %s {\n", name)
+	for _, v := range values {
+		if v == nil {
+			continue
+		}
+		value := strings.ToUpper(GraphQLName(strings.Join(SplitWords(fmt.Sprint(v)), "_")))
+		if seen[value] {
+			continue
+		}
+		seen[value] = true
+		fmt.Fprintf(&g.enums, "  %s\n", value)
+	}
+	g.enums.WriteString("}\n\n")
+}
+
+func propertyNames(t *schema.TableProperties) []string {
+	var names []string
+	for name := range t.Properties {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// writeObjectType declares the object type of a table. NOT NULL columns are
+// non-null and foreign keys add a field for the referenced row.
+func (g *graphQLDocument) writeObjectType(r *Request, t *schema.TableProperties, refs map[string]string) {
+	name := GraphQLName(PascalCase(t.Name))
+	var foreignKeys = make(map[string]*schema.ForeignKey)
+	for _, fk := range t.ForeignKeys {
+		foreignKeys[fk.Field] = fk
+	}
+	writeGraphQLDescription(&g.types, "", t.Description)
+	fmt.Fprintf(&g.types, "type %s {\n", name)
+	for _, column := range propertyNames(t) {
+		prop := t.Properties[column]
+		fieldType := g.scalarType(r, name+PascalCase(column), prop)
+		if !prop.Nullable {
+			fieldType += "!"
+		}
+		writeGraphQLDescription(&g.types, "  ", prop.Description)
+		fmt.Fprintf(&g.types, "  %s: %s\n", GraphQLName(column), fieldType)
+		fk, exists := foreignKeys[column]
+		if !exists {
+			continue
+		}
+		if ref, exists := refs[fk.ReferencedTable]; exists {
+			relationType := ref
+			if !prop.Nullable {
+				relationType += "!"
+			}
+			fmt.Fprintf(&g.types, "  %s: %s\n", GraphQLRelationName(column, fk.ReferencedTable, t), relationType)
+		}
+	}
+	g.types.WriteString("}\n\n")
+}
+
+// writeInputType declares the input type used to create rows of a table,
+// which omits readOnly columns and does not require columns with a default.
+func (g *graphQLDocument) writeInputType(r *Request, t *schema.TableProperties) {
+	object := GraphQLName(PascalCase(t.Name))
+	create := MakeVariant(t, VariantCreate)
+	var required = make(map[string]bool)
+	for _, column := range create.Required {
+		required[column] = true
+	}
+	fmt.Fprintf(&g.types, "input %sInput {\n", object)
+	for _, column := range propertyNames(create) {
+		prop := create.Properties[column]
+		fieldType := g.scalarType(r, object+PascalCase(column), prop)
+		if required[column] {
+			fieldType += "!"
+		}
+		writeGraphQLDescription(&g.types, "  ", prop.Description)
+		fmt.Fprintf(&g.types, "  %s: %s\n", GraphQLName(column), fieldType)
+	}
+	g.types.WriteString("}\n\n")
+}
+
+// MakeGraphQL renders every table as an object type and an input type.
+func (r *Request) MakeGraphQL(tables []*schema.TableProperties) string {
+	var refs = make(map[string]string)
+	for _, t := range tables {
+		refs[t.Name] = GraphQLName(PascalCase(t.Name))
+	}
+	g := &graphQLDocument{
+		scalars:  make(map[string]bool),
+		declared: make(map[string]bool),
+	}
+	for _, t := range tables {
+		g.writeObjectType(r, t, refs)
+		g.writeInputType(r, t)
+	}
+	var b strings.Builder
+	for _, scalar := range graphQLScalars {
+		if g.scalars[scalar] {
+			fmt.Fprintf(&b, "scalar %s\n", scalar)
+		}
+	}
+	if b.Len() > 0 {
+		b.WriteString("\n")
+	}
+	b.WriteString(g.enums.String())
+	b.WriteString(g.types.String())
+	return strings.TrimSuffix(b.String(), "\n")
+}
+
+// HandleGraphQLOutput writes the schema to the standard output or to
+// schema.graphql in the output directory.
+func (r *Request) HandleGraphQLOutput(tables []*schema.TableProperties) error {
+	res := r.MakeGraphQL(tables)
+	if len(r.Outdir) == 0 {
+		fmt.Print(res)
+		return nil
+	}
+	err := os.MkdirAll(r.Outdir, os.ModePerm)
+	if err != nil {
+		return err
+	}
+	outputPath := filepath.Join(r.Outdir, graphQLOutputFile)
+	log.Infof("Writing to %s", outputPath)
+	return os.WriteFile(outputPath, []byte(res), 0666)
+}
@@ -0,0 +1,73 @@
+package generator
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/tgallant/db2jsonschema/internal/schema"
+)
+
+func TestGraphQLRelationName(t *testing.T) {
+	tables := makeRelatedDbTables()
+	assert.Equal(t, "album", GraphQLRelationName("album_id", "albums", tables[1]), "the _id suffix should be removed")
+	assert.Equal(t, "album_albums", GraphQLRelationName("album", "albums", tables[1]), "columns without a suffix should name the table")
+}
+
+func TestMakeGraphQL(t *testing.T) {
+	r := &Request{}
+	tables := makeRelatedDbTables()
+	tables[0].Properties["released_at"] = &schema.Property{Type: "string", Format: "date-time", Nullable: true}
+	tables[0].Properties["genre"] = &schema.Property{Type: "string", Enum: []interface{}{"rock", "hip hop"}}
+	tables[0].Properties["price"] = &schema.Property{Type: "number", Precision: 5, Scale: 2, Nullable: true}
+	res := r.MakeGraphQL(tables)
+	assert.Contains(t, res, "scalar DateTime\nscalar Decimal\n", "the custom scalars should be declared")
+	assert.Contains(t, res, "enum AlbumsGenre {\n  ROCK\n  HIP_HOP\n}\n", "enum columns should be enums")
+	assert.Contains(t, res, "type Albums {\n  genre: AlbumsGenre!\n  id: Float!\n  price: Decimal\n  released_at: DateTime\n}\n", "each table should be an object type")
+	assert.Contains(t, res, "input AlbumsInput {\n", "each table should have an input type")
+	assert.Contains(t, res, "  album_id: Float!\n  album: Albums!\n", "foreign keys should add a relationship field")
+	assert.Contains(t, res, "  genre_id: Float\n  id: Float!\n}\n", "tables that are not generated should not be related")
+}
+
+func TestMakeGraphQLIntegers(t *testing.T) {
+	r := &Request{}
+	tables := []*schema.TableProperties{schema.MakeTableProperties(&schema.Table{
+		Name: "albums",
+		Fields: []*schema.Field{
+			{Name: "id", Type: &schema.FieldType{Name: "integer", Size: 64}},
+			{Name: "year", Type: &schema.FieldType{Name: "integer", Size: 16}},
+			{Name: "rating", Type: &schema.FieldType{Name: "integer"}},
+			{Name: "plays", Type: &schema.FieldType{Name: "integer", Size: 64, Unsigned: true}},
+		},
+	})}
+	res := r.MakeGraphQL(tables)
+	assert.Contains(t, res, "scalar BigInt\n", "the BigInt scalar should be declared")
+	assert.Contains(t, res, "type Albums {\n  id: BigInt!\n  plays: BigInt!\n  rating: Int!\n  year: Int!\n}\n", "only 64 bit integers should be BigInt")
+}
+
+func TestMakeGraphQLInputOmitsReadOnly(t *testing.T) {
+	r := &Request{}
+	albums := makeRelatedTables()[0]
+	albums.Fields[0].AutoIncrement = true
+	albums.Fields = append(albums.Fields,
+		&schema.Field{Name: "title", Type: &schema.FieldType{Name: "string"}},
+		&schema.Field{Name: "released", Type: &schema.FieldType{Name: "boolean"}, Default: false},
+	)
+	res := r.MakeGraphQL([]*schema.TableProperties{schema.MakeTableProperties(albums)})
+	assert.Contains(t, res, "input AlbumsInput {\n  released: Boolean\n  title: String!\n}", "the input type should only require columns without a default")
+}
+
+func TestHandleGraphQLOutput(t *testing.T) {
+	outdir := t.TempDir()
+	r := &Request{
+		Tables: []*schema.Table{makeDbTable()},
+		Format: "graphql",
+		Outdir: outdir,
+	}
+	err := r.Perform()
+	assert.Nil(t, err, "performing the request should succeed")
+	res, err := os.ReadFile(filepath.Join(outdir, "schema.graphql"))
+	assert.Nil(t, err, "the schema should be written to the output directory")
+	assert.Contains(t, string(res), "type Testing {\n", "the schema should declare the object types")
+}
@@ -0,0 +1,44 @@
+package generator
+
+import (
+	"strings"
+	"unicode"
+)
+
+// SplitWords splits a table or column name into its words, breaking on
+// punctuation and on lower to upper case transitions.
+func SplitWords(name string) []string {
+	var words []string
+	var word []rune
+	runes := []rune(name)
+	for i, c := range runes {
+		if !unicode.IsLetter(c) && !unicode.IsDigit(c) {
+			if len(word) > 0 {
+				words = append(words, string(word))
+				word = nil
+			}
+			continue
+		}
+		if unicode.IsUpper(c) && len(word) > 0 && unicode.IsLower(runes[i-1]) {
+			words = append(words, string(word))
+			word = nil
+		}
+		word = append(word, c)
+	}
+	if len(word) > 0 {
+		words = append(words, string(word))
+	}
+	return words
+}
+
+// PascalCase converts a name such as bird_sightings.create into
+// BirdSightingsCreate.
+func PascalCase(name string) string {
+	var b strings.Builder
+	for _, word := range SplitWords(name) {
+		runes := []rune(word)
+		b.WriteRune(unicode.ToUpper(runes[0]))
+		b.WriteString(string(runes[1:]))
+	}
+	return b.String()
+}
@@ -0,0 +1,83 @@
+package generator
+
+import (
+	"fmt"
+
+	"github.com/tgallant/db2jsonschema/internal/schema"
+)
+
+const (
+	defaultOpenAPIDraft   = "openapi-3.1"
+	defaultOpenAPITitle   = "Definitions"
+	defaultOpenAPIVersion = "1.0.0"
+)
+
+func (r *Request) IsOpenAPI() bool {
+	return r.GetFormat() == "openapi"
+}
+
+// GetOpenAPIDraft returns the draft used for the components of an OpenAPI
+// document. OpenAPI 3.0 uses the nullable keyword while 3.1 uses type arrays.
+func (r *Request) GetOpenAPIDraft() (*Draft, error) {
+	name := defaultOpenAPIDraft
+	if len(r.Draft) > 0 {
+		name = r.Draft
+	}
+	d, err := LookupDraft(name)
+	if err != nil {
+		return nil, err
+	}
+	if len(d.OpenAPI) == 0 {
+		return nil, fmt.Errorf("Unknown OpenAPI draft: %s", name)
+	}
+	return d, nil
+}
+
+// ComponentsPointer returns the JSON pointer of a schema within the
+// components of an OpenAPI document.
+func ComponentsPointer(name string) string {
+	return fmt.Sprintf("#/components/schemas/%s", name)
+}
+
+// MakeOpenAPIDoc places the schema of every table under components.schemas.
+// Foreign keys reference the schema of their table unless another references
+// mode is requested.
+func (r *Request) MakeOpenAPIDoc(tables []*schema.TableProperties) (*schema.OpenAPIDocument, error) {
+	d, err := r.GetOpenAPIDraft()
+	if err != nil {
+		return &schema.OpenAPIDocument{}, err
+	}
+	refs, err := r.MakeReferences(tables, func(name string) (string, error) {
+		return ComponentsPointer(name), nil
+	})
+	if err != nil {
+		return &schema.OpenAPIDocument{}, err
+	}
+	expanded, err := r.ExpandVariants(tables)
+	if err != nil {
+		return &schema.OpenAPIDocument{}, err
+	}
+	var schemas = make(map[string]*schema.JSONDefinition)
+	for _, t := range expanded {
+		schemas[t.Name] = &schema.JSONDefinition{
+			Description: t.Description,
+			Type:        "object",
+			Properties:  r.MakeProperties(d, t, refs),
+			Required:    t.Required,
+			PrimaryKey:  t.PrimaryKeys,
+			UniqueKeys:  t.UniqueKeys,
+		}
+	}
+	doc := &schema.OpenAPIDocument{
+		OpenAPI: d.OpenAPI,
+		Info: &schema.OpenAPIInfo{
+			Title:   defaultOpenAPITitle,
+			Version: defaultOpenAPIVersion,
+		},
+		Paths: map[string]interface{}{},
+		Components: &schema.OpenAPIComponents{
+			Schemas: schemas,
+		},
+	}
+	return doc, nil
+}
@@ -0,0 +1,60 @@
+package generator
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/tgallant/db2jsonschema/internal/schema"
+)
+
+func TestMakeOpenAPIDoc(t *testing.T) {
+	r := &Request{Format: "openapi"}
+	tables := makeRelatedDbTables()
+	tables[1].Properties["album_id"].Nullable = true
+	doc, err := r.MakeOpenAPIDoc(tables)
+	assert.Nil(t, err, "creating the OpenAPI doc should succeed")
+	assert.Equal(t, "3.1.0", doc.OpenAPI, "the document should default to OpenAPI 3.1")
+	assert.Equal(t, 2, len(doc.Components.Schemas), "every table should be a component")
+	property := doc.Components.Schemas["tracks"].Properties["album_id"]
+	assert.Equal(t, "#/components/schemas/albums", property.AnyOf[0].Ref, "the $ref should point into the components")
+	assert.Equal(t, "null", property.AnyOf[1].Type, "OpenAPI 3.1 should allow null with a type")
+	property = doc.Components.Schemas["tracks"].Properties["genre_id"]
+	assert.Equal(t, []string{"number", "null"}, property.Type, "OpenAPI 3.1 should use a type array")
+}
+
+func TestMakeOpenAPIDocNullable(t *testing.T) {
+	r := &Request{Format: "openapi", Draft: "openapi-3.0"}
+	tables := makeRelatedDbTables()
+	tables[1].Properties["album_id"].Nullable = true
+	doc, err := r.MakeOpenAPIDoc(tables)
+	assert.Nil(t, err, "creating the OpenAPI doc should succeed")
+	assert.Equal(t, "3.0.3", doc.OpenAPI, "the document should use OpenAPI 3.0")
+	property := doc.Components.Schemas["tracks"].Properties["album_id"]
+	assert.Equal(t, 1, len(property.AnyOf), "the reference should not allow a null type")
+	assert.True(t, property.Nullable, "OpenAPI 3.0 should use the nullable keyword")
+	property = doc.Components.Schemas["tracks"].Properties["genre_id"]
+	assert.Equal(t, "number", property.Type, "OpenAPI 3.0 should not use a type array")
+	assert.True(t, property.Nullable, "OpenAPI 3.0 should use the nullable keyword")
+}
+
+func TestMakeOpenAPIDocUnknownDraft(t *testing.T) {
+	r := &Request{Format: "openapi", Draft: "draft-07"}
+	_, err := r.MakeOpenAPIDoc(makeRelatedDbTables())
+	assert.NotNil(t, err, "a JSON Schema draft should not be used for OpenAPI")
+}
+
+func TestHandleOpenAPIOutput(t *testing.T) {
+	outdir := t.TempDir()
+	r := &Request{
+		Tables: []*schema.Table{makeDbTable()},
+		Format: "openapi",
+		Outdir: outdir,
+	}
+	err := r.Perform()
+	assert.Nil(t, err, "performing the request should succeed")
+	res, err := os.ReadFile(filepath.Join(outdir, "openapi.yaml"))
+	assert.Nil(t, err, "a single document should be written")
+	assert.Contains(t, string(res), "openapi: 3.1.0", "the document should be YAML")
+}
@@ -0,0 +1,441 @@
+package generator
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/tgallant/db2jsonschema/internal/schema"
+)
+
+const (
+	protoGeneratedHeader = "// Code generated by db2jsonschema. DO NOT EDIT.\n"
+	defaultProtoLockFile = "proto.lock.json"
+	// Field numbers 19000 to 19999 are reserved by protobuf.
+	protoFirstReserved = 19000
+	protoLastReserved  = 19999
+)
+
+var protoIdentifierRegexp = regexp.MustCompile(`[^A-Za-z0-9_]+`)
+
+var protoWrappers = map[string]string{
+	"string": "google.protobuf.StringValue",
+	"bytes":  "google.protobuf.BytesValue",
+	"int32":  "google.protobuf.Int32Value",
+	"int64":  "google.protobuf.Int64Value",
+	"uint64": "google.protobuf.UInt64Value",
+	"double": "google.protobuf.DoubleValue",
+	"bool":   "google.protobuf.BoolValue",
+}
+
+var protoImports = map[string]string{
+	"google.protobuf.Timestamp":   "google/protobuf/timestamp.proto",
+	"google.protobuf.Value":       "google/protobuf/struct.proto",
+	"google.protobuf.StringValue": "google/protobuf/wrappers.proto",
+	"google.protobuf.BytesValue":  "google/protobuf/wrappers.proto",
+	"google.protobuf.Int32Value":  "google/protobuf/wrappers.proto",
+	"google.protobuf.Int64Value":  "google/protobuf/wrappers.proto",
+	"google.protobuf.UInt64Value": "google/protobuf/wrappers.proto",
+	"google.protobuf.DoubleValue": "google/protobuf/wrappers.proto",
+	"google.protobuf.BoolValue":   "google/protobuf/wrappers.proto",
+}
+
+// ProtoNumbers assigns stable numbers to the fields of a message or the
+// values of an enum. Numbers of names that are removed are reserved so they
+// are never reused for another name.
+type ProtoNumbers struct {
+	Numbers  map[string]int `json:"numbers"`
+	Reserved map[string]int `json:"reserved,omitempty"`
+}
+
+type ProtoLockMessage struct {
+	Fields *ProtoNumbers            `json:"fields"`
+	Enums  map[string]*ProtoNumbers `json:"enums,omitempty"`
+}
+
+// ProtoLock persists the numbers of the fields and enum values of every
+// message across runs.
+type ProtoLock struct {
+	Messages map[string]*ProtoLockMessage `json:"messages"`
+}
+
+// Assign numbers the given names starting from first, keeping the numbers
+// already assigned to them.
+func (n *ProtoNumbers) Assign(names []string, first int) {
+	if n.Numbers == nil {
+		n.Numbers = make(map[string]int)
+	}
+	if n.Reserved == nil {
+		n.Reserved = make(map[string]int)
+	}
+	var current = make(map[string]bool)
+	for _, name := range names {
+		current[name] = true
+	}
+	for name, number := range n.Numbers {
+		if !current[name] {
+			n.Reserved[name] = number
+			delete(n.Numbers, name)
+		}
+	}
+	next := first
+	for _, numbers := range []map[string]int{n.Numbers, n.Reserved} {
+		for _, number := range numbers {
+			if number >= next {
+				next = number + 1
+			}
+		}
+	}
+	for _, name := range names {
+		if _, exists := n.Numbers[name]; exists {
+			continue
+		}
+		if number, exists := n.Reserved[name]; exists {
+			n.Numbers[name] = number
+			delete(n.Reserved, name)
+			continue
+		}
+		if next >= protoFirstReserved && next <= protoLastReserved {
+			next = protoLastReserved + 1
+		}
+		n.Numbers[name] = next
+		next++
+	}
+}
+
+// Names returns the names in the order of their numbers.
+func (n *ProtoNumbers) Names() []string {
+	var names []string
+	for name := range n.Numbers {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		return n.Numbers[names[i]] < n.Numbers[names[j]]
+	})
+	return names
+}
+
+func (l *ProtoLock) Message(name string) *ProtoLockMessage {
+	if l.Messages == nil {
+		l.Messages = make(map[string]*ProtoLockMessage)
+	}
+	m, exists := l.Messages[name]
+	if !exists {
+		m = &ProtoLockMessage{Fields: &ProtoNumbers{}}
+		l.Messages[name] = m
+	}
+	if m.Fields == nil {
+		m.Fields = &ProtoNumbers{}
+	}
+	return m
+}
+
+func (m *ProtoLockMessage) Enum(name string) *ProtoNumbers {
+	if m.Enums == nil {
+		m.Enums = make(map[string]*ProtoNumbers)
+	}
+	e, exists := m.Enums[name]
+	if !exists {
+		e = &ProtoNumbers{}
+		m.Enums[name] = e
+	}
+	return e
+}
+
+// GetLockFile returns the path of the lock file of the field numbers. It
+// defaults to a file in the output directory, without which numbers are
+// not persisted.
+func (r *Request) GetLockFile() string {
+	if len(r.LockFile) > 0 {
+		return r.LockFile
+	}
+	if len(r.Outdir) > 0 {
+		return filepath.Join(r.Outdir, defaultProtoLockFile)
+	}
+	return ""
+}
+
+func ReadProtoLock(path string) (*ProtoLock, error) {
+	lock := &ProtoLock{}
+	if len(path) == 0 {
+		return lock, nil
+	}
+	res, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return lock, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	err = json.Unmarshal(res, lock)
+	if err != nil {
+		return nil, fmt.Errorf("Invalid lock file %s: %s", path, err)
+	}
+	return lock, nil
+}
+
+func WriteProtoLock(path string, lock *ProtoLock) error {
+	if len(path) == 0 {
+		return nil
+	}
+	res, err := FormatJSON(lock)
+	if err != nil {
+		return err
+	}
+	log.Infof("Writing to %s", path)
+	return os.WriteFile(path, res, 0666)
+}
+
+// ProtoName converts a column name into a valid protobuf field name.
+func ProtoName(name string) string {
+	name = protoIdentifierRegexp.ReplaceAllString(name, "_")
+	if len(name) == 0 || !((name[0] >= 'A' && name[0] <= 'Z') || (name[0] >= 'a' && name[0] <= 'z')) {
+		name = "x" + name
+	}
+	return name
+}
+
+// ProtoEnumValueName converts an enum value into the name of an enum
+// constant prefixed by the enum, e.g. SIZE_SMALL.
+func ProtoEnumValueName(prefix string, value interface{}) string {
+	words := SplitWords(fmt.Sprint(value))
+	for i, word := range words {
+		words[i] = strings.ToUpper(word)
+	}
+	return strings.Join(append([]string{prefix}, words...), "_")
+}
+
+func protoEnumPrefix(enum string) string {
+	words := SplitWords(enum)
+	for i, word := range words {
+		words[i] = strings.ToUpper(word)
+	}
+	return strings.Join(words, "_")
+}
+
+// ProtoScalarType returns the protobuf type of the values of a property
+// that is not an enum, without NULL.
+func (r *Request) ProtoScalarType(prop *schema.Property) string {
+	switch prop.Type {
+	case "string":
+		if len(prop.ContentEncoding) > 0 || prop.Format == "byte" {
+			return "bytes"
+		}
+		if prop.Format == "date-time" {
+			return "google.protobuf.Timestamp"
+		}
+		return "string"
+	case "integer":
+		if prop.Format == "int32" {
+			return "int32"
+		}
+		if ExceedsInt64(prop) {
+			return "uint64"
+		}
+		return "int64"
+	case "number":
+		if prop.Precision > 0 && r.Decimal == DecimalString {
+			return "string"
+		}
+		return "double"
+	case "boolean":
+		return "bool"
+	default:
+		return "google.protobuf.Value"
+	}
+}
+
+type protoMessage struct {
+	imports map[string]bool
+	enums   strings.Builder
+	fields  strings.Builder
+}
+
+func (m *protoMessage) use(protoType string) {
+	if path, exists := protoImports[protoType]; exists {
+		m.imports[path] = true
+	}
+}
+
+// writeEnum declares an enum nested in the message. The zero value is
+// UNSPECIFIED, which also stands for NULL.
+func (m *protoMessage) writeEnum(name string, values []interface{}, numbers *ProtoNumbers) {
+	prefix := protoEnumPrefix(name)
+	var names []string
+	var seen = map[string]bool{prefix + "_UNSPECIFIED": true}
+	for _, v := range values {
+		if v == nil {
+			continue
+		}
+		valueName := ProtoEnumValueName(prefix, v)
+		for i := 2; seen[valueName]; i++ {
+			valueName = fmt.Sprintf("%s_%d", ProtoEnumValueName(prefix, v), i)
+		}
+		seen[valueName] = true
+		names = append(names, valueName)
+	}
+	numbers.Assign(names, 1)
+	fmt.Fprintf(&m.enums, "  enum %s {\n", name)
+	fmt.Fprintf(&m.enums, "    %s_UNSPECIFIED = 0;\n", prefix)
+	for _, valueName := range numbers.Names() {
+		fmt.Fprintf(&m.enums, "    %s = %d;\n", valueName, numbers.Numbers[valueName])
+	}
+	writeProtoReserved(&m.enums, "    ", numbers)
+	m.enums.WriteString("  }\n\n")
+}
+
+func writeProtoReserved(b *strings.Builder, indent string, numbers *ProtoNumbers) {
+	if len(numbers.Reserved) == 0 {
+		return
+	}
+	var names []string
+	var reserved []int
+	for name, number := range numbers.Reserved {
+		names = append(names, fmt.Sprintf("%q", ProtoName(name)))
+		reserved = append(reserved, number)
+	}
+	sort.Strings(names)
+	sort.Ints(reserved)
+	var values []string
+	for _, number := range reserved {
+		values = append(values, fmt.Sprint(number))
+	}
+	fmt.Fprintf(b, "%sreserved %s;\n", indent, strings.Join(values, ", "))
+	fmt.Fprintf(b, "%sreserved %s;\n", indent, strings.Join(names, ", "))
+}
+
+// fieldType returns the type of the field of a property, declaring the enum
+// of enum columns. Nullable scalars are wrapped in well-known wrapper types.
+func (m *protoMessage) fieldType(r *Request, name string, prop *schema.Property, lock *ProtoLockMessage) string {
+	if prop.Type == "array" {
+		items := &schema.Property{}
+		if prop.Items != nil {
+			items = prop.Items
+		}
+		return "repeated " + m.fieldType(r, name, items, lock)
+	}
+	if len(prop.Enum) > 0 {
+		enum := PascalCase(name)
+		m.writeEnum(enum, prop.Enum, lock.Enum(name))
+		return enum
+	}
+	protoType := r.ProtoScalarType(prop)
+	if wrapper, exists := protoWrappers[protoType]; exists && prop.Nullable {
+		protoType = wrapper
+	}
+	m.use(protoType)
+	return protoType
+}
+
+// MakeProtoMessage declares the message of a table variant, numbering its
+// fields with the lock.
+func (r *Request) MakeProtoMessage(t *schema.TableProperties, lock *ProtoLock) (string, map[string]bool) {
+	messageName := PascalCase(t.Name)
+	lockMessage := lock.Message(messageName)
+	var columns []string
+	for name := range t.Properties {
+		columns = append(columns, name)
+	}
+	sort.Strings(columns)
+	lockMessage.Fields.Assign(columns, 1)
+	m := &protoMessage{imports: make(map[string]bool)}
+	var enums = make(map[string]bool)
+	for _, name := range lockMessage.Fields.Names() {
+		prop := t.Properties[name]
+		fieldType := m.fieldType(r, name, prop, lockMessage)
+		if len(prop.Enum) > 0 || (prop.Items != nil && len(prop.Items.Enum) > 0) {
+			enums[name] = true
+		}
+		if len(prop.Description) > 0 {
+			writeLineComment(&m.fields, "  ", prop.Description)
+		}
+		fmt.Fprintf(&m.fields, "  %s %s = %d;\n", fieldType, ProtoName(name), lockMessage.Fields.Numbers[name])
+	}
+	for name := range lockMessage.Enums {
+		if !enums[name] {
+			delete(lockMessage.Enums, name)
+		}
+	}
+	writeProtoReserved(&m.fields, "  ", lockMessage.Fields)
+	var b strings.Builder
+	if len(t.Description) > 0 {
+		writeLineComment(&b, "", t.Description)
+	}
+	fmt.Fprintf(&b, "message %s {\n", messageName)
+	b.WriteString(m.enums.String())
+	b.WriteString(m.fields.String())
+	b.WriteString("}\n")
+	return b.String(), m.imports
+}
+
+// MakeProto declares a message for every requested variant of every table
+// in a single proto3 file.
+func (r *Request) MakeProto(tables []*schema.TableProperties, lock *ProtoLock) (string, error) {
+	expanded, err := r.ExpandVariants(tables)
+	if err != nil {
+		return "", err
+	}
+	var imports = make(map[string]bool)
+	var messages []string
+	for _, t := range expanded {
+		message, messageImports := r.MakeProtoMessage(t, lock)
+		for path := range messageImports {
+			imports[path] = true
+		}
+		messages = append(messages, message)
+	}
+	var paths []string
+	for path := range imports {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	var b strings.Builder
+	b.WriteString(protoGeneratedHeader)
+	b.WriteString("\nsyntax = \"proto3\";\n")
+	fmt.Fprintf(&b, "\npackage %s;\n", r.GetPackage())
+	if len(paths) > 0 {
+		b.WriteString("\n")
+	}
+	for _, path := range paths {
+		fmt.Fprintf(&b, "import %q;\n", path)
+	}
+	b.WriteString("\n")
+	b.WriteString(strings.Join(messages, "\n"))
+	return b.String(), nil
+}
+
+// HandleProtoOutput writes the proto file to the standard output or to
+// <package>.proto in the output directory, then updates the lock file.
+func (r *Request) HandleProtoOutput(tables []*schema.TableProperties) error {
+	lockFile := r.GetLockFile()
+	lock, err := ReadProtoLock(lockFile)
+	if err != nil {
+		return err
+	}
+	res, err := r.MakeProto(tables, lock)
+	if err != nil {
+		return err
+	}
+	if len(r.Outdir) == 0 {
+		fmt.Print(res)
+		return WriteProtoLock(lockFile, lock)
+	}
+	err = os.MkdirAll(r.Outdir, os.ModePerm)
+	if err != nil {
+		return err
+	}
+	outputPath := filepath.Join(r.Outdir, fmt.Sprintf("%s.proto", r.GetPackage()))
+	log.Infof("Writing to %s", outputPath)
+	err = os.WriteFile(outputPath, []byte(res), 0666)
+	if err != nil {
+		return err
+	}
+	return WriteProtoLock(lockFile, lock)
+}
@@ -0,0 +1,88 @@
+package generator
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/tgallant/db2jsonschema/internal/schema"
+)
+
+func TestProtoNumbersAssign(t *testing.T) {
+	n := &ProtoNumbers{}
+	n.Assign([]string{"id", "name", "notes"}, 1)
+	assert.Equal(t, map[string]int{"id": 1, "name": 2, "notes": 3}, n.Numbers, "names should be numbered in order")
+	n.Assign([]string{"id", "created_at", "name"}, 1)
+	assert.Equal(t, map[string]int{"id": 1, "name": 2, "created_at": 4}, n.Numbers, "existing names should keep their numbers")
+	assert.Equal(t, map[string]int{"notes": 3}, n.Reserved, "removed names should be reserved")
+	n.Assign([]string{"id", "created_at", "name", "notes"}, 1)
+	assert.Equal(t, 3, n.Numbers["notes"], "restored names should get their number back")
+	assert.Empty(t, n.Reserved, "restored names should not be reserved")
+}
+
+func TestProtoNumbersAssignSkipsReservedRange(t *testing.T) {
+	n := &ProtoNumbers{Numbers: map[string]int{"id": 18999}}
+	n.Assign([]string{"id", "name"}, 1)
+	assert.Equal(t, 20000, n.Numbers["name"], "the numbers reserved by protobuf should be skipped")
+}
+
+func TestMakeProto(t *testing.T) {
+	r := &Request{Package: "birds"}
+	tables := makeRelatedTables()
+	tables[0].Comment = "Released albums"
+	tables[0].Fields = append(tables[0].Fields,
+		&schema.Field{Name: "released_at", Type: &schema.FieldType{Name: "string", Format: "date-time"}},
+		&schema.Field{Name: "notes", Type: &schema.FieldType{Name: "string"}, Nullable: true},
+		&schema.Field{Name: "genre", Type: &schema.FieldType{Name: "string", Enum: []interface{}{"rock", "hip hop"}}},
+	)
+	res, err := r.MakeProto([]*schema.TableProperties{schema.MakeTableProperties(tables[0])}, &ProtoLock{})
+	assert.Nil(t, err, "creating the proto file should succeed")
+	assert.Contains(t, res, "syntax = \"proto3\";\n", "the file should use proto3")
+	assert.Contains(t, res, "package birds;\n", "the package name should be used")
+	assert.Contains(t, res, "import \"google/protobuf/timestamp.proto\";\n", "the well-known types should be imported")
+	assert.Contains(t, res, "// Released albums\nmessage Albums {\n", "each table should be a message")
+	assert.Contains(t, res, "  google.protobuf.StringValue notes = 3;\n", "nullable columns should be wrapped")
+	assert.Contains(t, res, "  google.protobuf.Timestamp released_at = 4;\n", "timestamps should use the Timestamp type")
+	assert.Contains(t, res, "  enum Genre {\n    GENRE_UNSPECIFIED = 0;\n    GENRE_ROCK = 1;\n    GENRE_HIP_HOP = 2;\n  }\n", "enum columns should be enums")
+	assert.Contains(t, res, "  Genre genre = 1;\n", "enum columns should use their enum")
+}
+
+func TestProtoScalarType(t *testing.T) {
+	r := &Request{}
+	assert.Equal(t, "int32", r.ProtoScalarType(schema.MakeProperty(&schema.FieldType{Name: "integer", Size: 32})), "int columns should use int32")
+	assert.Equal(t, "int64", r.ProtoScalarType(schema.MakeProperty(&schema.FieldType{Name: "integer", Size: 64})), "bigint columns should use int64")
+	assert.Equal(t, "bytes", r.ProtoScalarType(schema.MakeProperty(&schema.FieldType{Name: "string", ContentEncoding: "base64"})), "blob columns should use bytes")
+	assert.Equal(t, "uint64", r.ProtoScalarType(schema.MakeProperty(&schema.FieldType{Name: "integer", Size: 64, Unsigned: true})), "unsigned bigint columns should use uint64")
+}
+
+func TestHandleProtoOutputLockFile(t *testing.T) {
+	outdir := t.TempDir()
+	table := &schema.Table{
+		Name: "albums",
+		Fields: []*schema.Field{
+			{Name: "id", Type: &schema.FieldType{Name: "integer"}},
+			{Name: "title", Type: &schema.FieldType{Name: "string"}},
+		},
+	}
+	r := &Request{
+		Tables: []*schema.Table{table},
+		Format: "proto",
+		Outdir: outdir,
+	}
+	err := r.Perform()
+	assert.Nil(t, err, "performing the request should succeed")
+	_, err = os.Stat(filepath.Join(outdir, "proto.lock.json"))
+	assert.Nil(t, err, "the lock file should be written to the output directory")
+	table.Fields = []*schema.Field{
+		{Name: "artist", Type: &schema.FieldType{Name: "string"}},
+		{Name: "title", Type: &schema.FieldType{Name: "string"}},
+	}
+	err = r.Perform()
+	assert.Nil(t, err, "performing the request again should succeed")
+	res, err := os.ReadFile(filepath.Join(outdir, "models.proto"))
+	assert.Nil(t, err, "the proto file should be written")
+	assert.Contains(t, string(res), "  string title = 2;\n", "fields should keep their numbers across runs")
+	assert.Contains(t, string(res), "  string artist = 3;\n", "new fields should not reuse numbers")
+	assert.Contains(t, string(res), "  reserved 1;\n  reserved \"id\";\n", "removed fields should be reserved")
+}
@@ -0,0 +1,331 @@
+package generator
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/template"
+	"unicode"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/tgallant/db2jsonschema/internal/schema"
+)
+
+const templateExtension = ".tmpl"
+
+// TemplateModel is the data a template is executed against. Templates whose
+// file name contains an action, such as {{ pascalCase .Name }}.kt.tmpl, are
+// executed once per table against a TemplateTable instead and the file name
+// is executed against the same table.
+type TemplateModel struct {
+	Tables []*TemplateTable
+}
+
+// TemplateTable describes a table along with its keys and relations.
+// ForeignKeys lists the foreign keys of the table and ReferencedBy the
+// foreign keys of other tables referencing it.
+type TemplateTable struct {
+	Name         string
+	Comment      string
+	Fields       []*TemplateField
+	PrimaryKeys  []string
+	UniqueKeys   [][]string
+	ForeignKeys  []*TemplateRelation
+	ReferencedBy []*TemplateRelation
+	// Schema is the JSON Schema of the rows of the table.
+	Schema *schema.TableProperties
+}
+
+// TemplateField describes a column. It embeds the schema.Field read from
+// the database, so templates can use .Name, .Type.Name, .Nullable, .Comment,
+// .Default and the other fields of schema.Field.
+type TemplateField struct {
+	*schema.Field
+	PrimaryKey bool
+	// ForeignKey is the foreign key of the column, if any.
+	ForeignKey *TemplateRelation
+	// Property is the JSON Schema of the column.
+	Property *schema.Property
+}
+
+// TemplateRelation is a foreign key from Table.Field to
+// ReferencedTable.ReferencedField.
+type TemplateRelation struct {
+	Table           string
+	Field           string
+	ReferencedTable string
+	ReferencedField string
+}
+
+// MakeTemplateModel describes the tables, relating each foreign key to both
+// of its tables.
+func MakeTemplateModel(tables []*schema.Table) *TemplateModel {
+	model := &TemplateModel{}
+	var byName = make(map[string]*TemplateTable)
+	for _, t := range tables {
+		props := schema.MakeTableProperties(t)
+		table := &TemplateTable{
+			Name:        t.Name,
+			Comment:     t.Comment,
+			PrimaryKeys: t.PrimaryKeys,
+			UniqueKeys:  t.UniqueKeys,
+			Schema:      props,
+		}
+		var primaryKeys = make(map[string]bool)
+		for _, name := range t.PrimaryKeys {
+			primaryKeys[name] = true
+		}
+		var foreignKeys = make(map[string]*TemplateRelation)
+		for _, fk := range t.ForeignKeys {
+			relation := &TemplateRelation{
+				Table:           t.Name,
+				Field:           fk.Field,
+				ReferencedTable: fk.ReferencedTable,
+				ReferencedField: fk.ReferencedField,
+			}
+			foreignKeys[fk.Field] = relation
+			table.ForeignKeys = append(table.ForeignKeys, relation)
+		}
+		for _, field := range t.Fields {
+			table.Fields = append(table.Fields, &TemplateField{
+				Field:      field,
+				PrimaryKey: primaryKeys[field.Name],
+				ForeignKey: foreignKeys[field.Name],
+				Property:   props.Properties[field.Name],
+			})
+		}
+		byName[t.Name] = table
+		model.Tables = append(model.Tables, table)
+	}
+	for _, table := range model.Tables {
+		for _, relation := range table.ForeignKeys {
+			if referenced, exists := byName[relation.ReferencedTable]; exists {
+				referenced.ReferencedBy = append(referenced.ReferencedBy, relation)
+			}
+		}
+	}
+	return model
+}
+
+// CamelCase converts a name such as bird_sightings into birdSightings.
+func CamelCase(name string) string {
+	pascal := []rune(PascalCase(name))
+	if len(pascal) == 0 {
+		return ""
+	}
+	return string(unicode.ToLower(pascal[0])) + string(pascal[1:])
+}
+
+// SnakeCase converts a name such as birdSightings into bird_sightings.
+func SnakeCase(name string) string {
+	words := SplitWords(name)
+	for i, word := range words {
+		words[i] = strings.ToLower(word)
+	}
+	return strings.Join(words, "_")
+}
+
+// Pluralize returns the English plural of a singular noun.
+func Pluralize(word string) string {
+	lower := strings.ToLower(word)
+	switch {
+	case len(word) == 0:
+		return word
+	case strings.HasSuffix(lower, "s") || strings.HasSuffix(lower, "x") ||
+		strings.HasSuffix(lower, "z") || strings.HasSuffix(lower, "ch") ||
+		strings.HasSuffix(lower, "sh"):
+		return word + "es"
+	case strings.HasSuffix(lower, "y") && len(lower) > 1 && !strings.ContainsRune("aeiou", rune(lower[len(lower)-2])):
+		return word[:len(word)-1] + "ies"
+	default:
+		return word + "s"
+	}
+}
+
+// Singularize returns the English singular of a plural noun. It undoes
+// Pluralize for regular nouns, guessing whether -es was added from the
+// letters before it.
+func Singularize(word string) string {
+	lower := strings.ToLower(word)
+	switch {
+	case strings.HasSuffix(lower, "ies") && len(lower) > 3:
+		return word[:len(word)-3] + "y"
+	case strings.HasSuffix(lower, "sses") || strings.HasSuffix(lower, "xes") ||
+		strings.HasSuffix(lower, "ches") || strings.HasSuffix(lower, "shes"):
+		return word[:len(word)-2]
+	case strings.HasSuffix(lower, "uses") && len(lower) > 4 && !strings.ContainsRune("aeiou", rune(lower[len(lower)-5])):
+		// statuses and buses, but not causes or houses.
+		return word[:len(word)-2]
+	case strings.HasSuffix(lower, "ss") || strings.HasSuffix(lower, "us") ||
+		strings.HasSuffix(lower, "is"):
+		return word
+	case strings.HasSuffix(lower, "s"):
+		return word[:len(word)-1]
+	default:
+		return word
+	}
+}
+
+// JSONType returns the JSON Schema type of a column, or an empty string for
+// columns that hold any JSON value.
+func JSONType(field *TemplateField) string {
+	if field == nil || field.Type == nil {
+		return ""
+	}
+	return field.Type.Name
+}
+
+func toJSON(value interface{}) (string, error) {
+	res, err := json.Marshal(value)
+	if err != nil {
+		return "", err
+	}
+	return string(res), nil
+}
+
+// TemplateFuncs are the helper functions available to templates.
+var TemplateFuncs = template.FuncMap{
+	"camelCase":   CamelCase,
+	"pascalCase":  PascalCase,
+	"snakeCase":   SnakeCase,
+	"pluralize":   Pluralize,
+	"singularize": Singularize,
+	"jsonType":    JSONType,
+	"upper":       strings.ToUpper,
+	"lower":       strings.ToLower,
+	"join":        strings.Join,
+	"toJSON":      toJSON,
+}
+
+func executeTemplate(name string, text string, data interface{}) (string, error) {
+	t, err := template.New(name).Funcs(TemplateFuncs).Parse(text)
+	if err != nil {
+		return "", err
+	}
+	var res bytes.Buffer
+	err = t.Execute(&res, data)
+	if err != nil {
+		return "", err
+	}
+	return res.String(), nil
+}
+
+// ListTemplates returns the template files of a template path, relative to
+// it. A file is its own single template, while only the files of a directory
+// with the template extension are templates.
+func ListTemplates(path string) (string, []string, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return "", nil, err
+	}
+	if !info.IsDir() {
+		return filepath.Dir(path), []string{filepath.Base(path)}, nil
+	}
+	var files []string
+	err = filepath.WalkDir(path, func(p string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() || filepath.Ext(p) != templateExtension {
+			return err
+		}
+		rel, err := filepath.Rel(path, p)
+		if err != nil {
+			return err
+		}
+		files = append(files, rel)
+		return nil
+	})
+	if err != nil {
+		return "", nil, err
+	}
+	sort.Strings(files)
+	return path, files, nil
+}
+
+// joinOutdir joins a slash separated path to the output directory,
+// rejecting absolute paths and paths that escape it.
+func joinOutdir(outdir string, path string) (string, error) {
+	native := filepath.FromSlash(path)
+	outputPath := filepath.Join(outdir, native)
+	rel, err := filepath.Rel(outdir, outputPath)
+	if err != nil || filepath.IsAbs(native) || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("Invalid output path: %s", path)
+	}
+	return outputPath, nil
+}
+
+// RenderedTemplate is the output of a template and the path it is written
+// to, relative to the output directory.
+type RenderedTemplate struct {
+	Path   string
+	Output string
+}
+
+// RenderTemplate executes a template against the model, or against each
+// table when its file name is a template too.
+func RenderTemplate(name string, text string, model *TemplateModel) ([]*RenderedTemplate, error) {
+	outputPath := strings.TrimSuffix(name, templateExtension)
+	if !strings.Contains(outputPath, "{{") {
+		res, err := executeTemplate(name, text, model)
+		if err != nil {
+			return nil, err
+		}
+		return []*RenderedTemplate{{Path: outputPath, Output: res}}, nil
+	}
+	var rendered []*RenderedTemplate
+	for _, table := range model.Tables {
+		tablePath, err := executeTemplate(name, outputPath, table)
+		if err != nil {
+			return nil, err
+		}
+		res, err := executeTemplate(name, text, table)
+		if err != nil {
+			return nil, err
+		}
+		rendered = append(rendered, &RenderedTemplate{Path: tablePath, Output: res})
+	}
+	return rendered, nil
+}
+
+// HandleTemplateOutput executes the templates given by the template path,
+// printing their output or writing it to the output directory.
+func (r *Request) HandleTemplateOutput() error {
+	dir, files, err := ListTemplates(r.Template)
+	if err != nil {
+		return err
+	}
+	model := MakeTemplateModel(r.Tables)
+	for _, file := range files {
+		text, err := os.ReadFile(filepath.Join(dir, file))
+		if err != nil {
+			return err
+		}
+		rendered, err := RenderTemplate(filepath.ToSlash(file), string(text), model)
+		if err != nil {
+			return fmt.Errorf("Invalid template %s: %s", file, err)
+		}
+		for _, t := range rendered {
+			if len(r.Outdir) == 0 {
+				fmt.Print(t.Output)
+				continue
+			}
+			outputPath, err := joinOutdir(r.Outdir, t.Path)
+			if err != nil {
+				return err
+			}
+			err = os.MkdirAll(filepath.Dir(outputPath), os.ModePerm)
+			if err != nil {
+				return err
+			}
+			log.Infof("Writing to %s", outputPath)
+			err = os.WriteFile(outputPath, []byte(t.Output), 0666)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,111 @@
+package generator
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTemplateHelpers(t *testing.T) {
+	assert.Equal(t, "birdSightings", CamelCase("bird_sightings"), "camelCase should lower the first word")
+	assert.Equal(t, "bird_sightings", SnakeCase("BirdSightings"), "snakeCase should join lower case words")
+	assert.Equal(t, "albums", Pluralize("album"), "nouns should get an s")
+	assert.Equal(t, "categories", Pluralize("category"), "a consonant followed by y should become ies")
+	assert.Equal(t, "boxes", Pluralize("box"), "sibilants should get es")
+	assert.Equal(t, "days", Pluralize("day"), "a vowel followed by y should get an s")
+}
+
+func TestSingularize(t *testing.T) {
+	cases := map[string]string{
+		"albums":     "album",
+		"categories": "category",
+		"days":       "day",
+		"boxes":      "box",
+		"matches":    "match",
+		"wishes":     "wish",
+		"classes":    "class",
+		"statuses":   "status",
+		"buses":      "bus",
+		"causes":     "cause",
+		"houses":     "house",
+		"sizes":      "size",
+		"status":     "status",
+		"analysis":   "analysis",
+		"Albums":     "Album",
+	}
+	for plural, expected := range cases {
+		assert.Equalf(t, expected, Singularize(plural), "the singular of `%s` should be %s", plural, expected)
+		if plural != expected {
+			assert.Equalf(t, plural, Pluralize(expected), "the plural of `%s` should be %s", expected, plural)
+		}
+	}
+}
+
+func TestMakeTemplateModel(t *testing.T) {
+	model := MakeTemplateModel(makeRelatedTables())
+	albums := model.Tables[0]
+	assert.True(t, albums.Fields[0].PrimaryKey, "primary key columns should be marked")
+	assert.Equal(t, "number", JSONType(albums.Fields[0]), "jsonType should return the JSON Schema type")
+	assert.Equal(t, "number", albums.Fields[0].Property.Type, "fields should have their JSON Schema")
+	assert.Equal(t, 1, len(albums.ReferencedBy), "incoming foreign keys should be listed")
+	assert.Equal(t, "tracks", albums.ReferencedBy[0].Table, "incoming foreign keys should name their table")
+	tracks := model.Tables[1]
+	assert.Equal(t, "albums", tracks.Fields[1].ForeignKey.ReferencedTable, "foreign key columns should have their relation")
+}
+
+func TestRenderTemplate(t *testing.T) {
+	model := MakeTemplateModel(makeRelatedTables())
+	rendered, err := RenderTemplate("index.md.tmpl", "{{ range .Tables }}{{ singularize (pascalCase .Name) }}\n{{ end }}", model)
+	assert.Nil(t, err, "rendering the template should succeed")
+	assert.Equal(t, []*RenderedTemplate{{Path: "index.md", Output: "Album\nTrack\n"}}, rendered, "the template should be executed against the model")
+	rendered, err = RenderTemplate("{{ .Name }}.kt.tmpl", "{{ range .Fields }}{{ camelCase .Name }}{{ end }}", model)
+	assert.Nil(t, err, "rendering the template should succeed")
+	assert.Equal(t, 2, len(rendered), "the template should be executed per table")
+	assert.Equal(t, "tracks.kt", rendered[1].Path, "the file name should be executed against the table")
+	assert.Equal(t, "idalbumIdgenreId", rendered[1].Output, "the template should be executed against the table")
+	_, err = RenderTemplate("broken.tmpl", "{{ .Missing }", model)
+	assert.NotNil(t, err, "an invalid template should fail")
+}
+
+func TestHandleTemplateOutput(t *testing.T) {
+	templates := t.TempDir()
+	err := os.WriteFile(filepath.Join(templates, "{{ .Name }}.sql.tmpl"), []byte("DELETE FROM {{ .Name }};\n"), 0666)
+	assert.Nil(t, err, "writing the template should succeed")
+	err = os.WriteFile(filepath.Join(templates, "README.md"), []byte("{{ .Missing }"), 0666)
+	assert.Nil(t, err, "writing the readme should succeed")
+	outdir := t.TempDir()
+	r := &Request{
+		Tables:   makeRelatedTables(),
+		Outdir:   outdir,
+		Template: templates,
+	}
+	err = r.Perform()
+	assert.Nil(t, err, "performing the request should succeed")
+	res, err := os.ReadFile(filepath.Join(outdir, "albums.sql"))
+	assert.Nil(t, err, "each table should be written to its own file")
+	assert.Equal(t, "DELETE FROM albums;\n", string(res), "the template should be executed")
+	_, err = os.Stat(filepath.Join(outdir, "README.md"))
+	assert.True(t, os.IsNotExist(err), "files without the template extension should be ignored")
+}
+
+func TestHandleTemplateOutputEscapingPath(t *testing.T) {
+	templates := t.TempDir()
+	err := os.WriteFile(filepath.Join(templates, "{{ .Name }}.sql.tmpl"), []byte("DELETE FROM {{ .Name }};\n"), 0666)
+	assert.Nil(t, err, "writing the template should succeed")
+	outdir := filepath.Join(t.TempDir(), "out")
+	for _, name := range []string{"../albums", "/tmp/albums"} {
+		tables := makeRelatedTables()[:1]
+		tables[0].Name = name
+		r := &Request{
+			Tables:   tables,
+			Outdir:   outdir,
+			Template: templates,
+		}
+		err = r.Perform()
+		assert.NotNilf(t, err, "writing %s outside of the output directory should fail", name)
+	}
+	_, err = os.Stat(filepath.Join(outdir, "..", "albums.sql"))
+	assert.True(t, os.IsNotExist(err), "nothing should be written outside of the output directory")
+}
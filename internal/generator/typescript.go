@@ -0,0 +1,227 @@
+package generator
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/tgallant/db2jsonschema/internal/schema"
+)
+
+const typeScriptHeader = "// Code generated by db2jsonschema. DO NOT EDIT.\n"
+
+var typeScriptIdentifierRegexp = regexp.MustCompile(`^[A-Za-z_$][A-Za-z0-9_$]*$`)
+
+// TypeScriptInterface is the declaration of the interface of a table
+// variant. References lists the tables whose interfaces it uses.
+type TypeScriptInterface struct {
+	Name       string
+	Interface  string
+	References []string
+	Source     string
+}
+
+func TypeScriptPropertyName(name string) string {
+	if typeScriptIdentifierRegexp.MatchString(name) {
+		return name
+	}
+	return TypeScriptLiteral(name)
+}
+
+func TypeScriptLiteral(value interface{}) string {
+	res, err := json.Marshal(value)
+	if err != nil {
+		return "unknown"
+	}
+	return string(res)
+}
+
+func writeTypeScriptComment(b *strings.Builder, indent string, text string) {
+	if len(text) == 0 {
+		return
+	}
+	text = strings.ReplaceAll(text, "*/", "*\\/")
+	lines := strings.Split(text, "\n")
+	if len(lines) == 1 {
+		fmt.Fprintf(b, "%s/** %s */\n", indent, text)
+		return
+	}
+	fmt.Fprintf(b, "%s/**\n", indent)
+	for _, line := range lines {
+		fmt.Fprintf(b, "%s * %s\n", indent, line)
+	}
+	fmt.Fprintf(b, "%s */\n", indent)
+}
+
+// TypeScriptType returns the TypeScript type of the values of a property,
+// without null. Enums become unions of their literals.
+func (r *Request) TypeScriptType(prop *schema.Property) string {
+	if len(prop.Enum) > 0 {
+		var literals []string
+		for _, v := range prop.Enum {
+			literals = append(literals, TypeScriptLiteral(v))
+		}
+		return strings.Join(literals, " | ")
+	}
+	switch prop.Type {
+	case "string":
+		return "string"
+	case "integer":
+		return "number"
+	case "number":
+		if prop.Precision > 0 && r.Decimal == DecimalString {
+			return "string"
+		}
+		return "number"
+	case "boolean":
+		return "boolean"
+	case "array":
+		items := "unknown"
+		if prop.Items != nil {
+			items = r.TypeScriptType(prop.Items)
+		}
+		if strings.Contains(items, " ") {
+			items = fmt.Sprintf("(%s)", items)
+		}
+		return items + "[]"
+	case "object":
+		return "Record<string, unknown>"
+	default:
+		return "unknown"
+	}
+}
+
+// MakeTypeScriptInterface declares the interface of a table variant. Foreign
+// keys are typed as the ID of the interface of the referenced table when it
+// is generated.
+func (r *Request) MakeTypeScriptInterface(t *schema.TableProperties, refs map[string]string) *TypeScriptInterface {
+	var foreignKeys = make(map[string]*schema.ForeignKey)
+	for _, fk := range t.ForeignKeys {
+		foreignKeys[fk.Field] = fk
+	}
+	var required = make(map[string]bool)
+	for _, name := range t.Required {
+		required[name] = true
+	}
+	var names []string
+	for name := range t.Properties {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	ts := &TypeScriptInterface{
+		Name:      t.Name,
+		Interface: PascalCase(t.Name),
+	}
+	var b strings.Builder
+	writeTypeScriptComment(&b, "", t.Description)
+	fmt.Fprintf(&b, "export interface %s {\n", ts.Interface)
+	for _, name := range names {
+		prop := t.Properties[name]
+		propType := r.TypeScriptType(prop)
+		if fk, exists := foreignKeys[name]; exists {
+			if ref, exists := refs[fk.ReferencedTable]; exists {
+				propType = fmt.Sprintf("%s[%s]", ref, TypeScriptLiteral(fk.ReferencedField))
+				if fk.ReferencedTable != t.Name {
+					ts.References = append(ts.References, fk.ReferencedTable)
+				}
+			}
+		}
+		if prop.Nullable {
+			propType += " | null"
+		}
+		optional := ""
+		if !required[name] {
+			optional = "?"
+		}
+		writeTypeScriptComment(&b, "  ", prop.Description)
+		fmt.Fprintf(&b, "  %s%s: %s;\n", TypeScriptPropertyName(name), optional, propType)
+	}
+	b.WriteString("}\n")
+	ts.Source = b.String()
+	return ts
+}
+
+// MakeTypeScript declares the interface of every requested variant of every
+// table.
+func (r *Request) MakeTypeScript(tables []*schema.TableProperties) ([]*TypeScriptInterface, error) {
+	expanded, err := r.ExpandVariants(tables)
+	if err != nil {
+		return nil, err
+	}
+	var generated = make(map[string]bool)
+	for _, t := range expanded {
+		generated[t.Name] = true
+	}
+	var refs = make(map[string]string)
+	for _, t := range tables {
+		if generated[t.Name] {
+			refs[t.Name] = PascalCase(t.Name)
+		}
+	}
+	var interfaces []*TypeScriptInterface
+	for _, t := range expanded {
+		interfaces = append(interfaces, r.MakeTypeScriptInterface(t, refs))
+	}
+	return interfaces, nil
+}
+
+// HandleTypeScriptOutput writes every interface to a single file on the
+// standard output, or each interface to its own module in the output
+// directory.
+func (r *Request) HandleTypeScriptOutput(tables []*schema.TableProperties) error {
+	interfaces, err := r.MakeTypeScript(tables)
+	if err != nil {
+		return err
+	}
+	if len(r.Outdir) == 0 {
+		var sources []string
+		for _, ts := range interfaces {
+			sources = append(sources, ts.Source)
+		}
+		fmt.Print(typeScriptHeader + "\n" + strings.Join(sources, "\n"))
+		return nil
+	}
+	err = os.MkdirAll(r.Outdir, os.ModePerm)
+	if err != nil {
+		return err
+	}
+	for _, ts := range interfaces {
+		outputPath := filepath.Join(r.Outdir, fmt.Sprintf("%s.ts", ts.Name))
+		log.Infof("Writing to %s", outputPath)
+		err = os.WriteFile(outputPath, []byte(ts.Module()), 0666)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Module returns the source of a module exporting the interface and
+// importing the interfaces it references.
+func (ts *TypeScriptInterface) Module() string {
+	var b strings.Builder
+	b.WriteString(typeScriptHeader)
+	var imports = make(map[string]bool)
+	var tables []string
+	for _, table := range ts.References {
+		if !imports[table] {
+			imports[table] = true
+			tables = append(tables, table)
+		}
+	}
+	sort.Strings(tables)
+	if len(tables) > 0 {
+		b.WriteString("\n")
+	}
+	for _, table := range tables {
+		fmt.Fprintf(&b, "import type { %s } from \"./%s\";\n", PascalCase(table), table)
+	}
+	b.WriteString("\n")
+	b.WriteString(ts.Source)
+	return b.String()
+}
@@ -0,0 +1,67 @@
+package generator
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/tgallant/db2jsonschema/internal/schema"
+)
+
+func TestPascalCase(t *testing.T) {
+	assert.Equal(t, "BirdSightings", PascalCase("bird_sightings"), "underscores should separate words")
+	assert.Equal(t, "AlbumsCreate", PascalCase("albums.create"), "dots should separate words")
+	assert.Equal(t, "UserId", PascalCase("userId"), "camel case words should be kept")
+}
+
+func TestTypeScriptType(t *testing.T) {
+	r := &Request{}
+	assert.Equal(t, "string", r.TypeScriptType(&schema.Property{Type: "string", Format: "date-time"}), "date-time should be a string")
+	assert.Equal(t, "number", r.TypeScriptType(&schema.Property{Type: "integer"}), "integers should be numbers")
+	assert.Equal(t, `"small" | "large"`, r.TypeScriptType(&schema.Property{Type: "string", Enum: []interface{}{"small", "large"}}), "enums should be unions of literals")
+	assert.Equal(t, `("a" | "b")[]`, r.TypeScriptType(&schema.Property{Type: "array", Items: &schema.Property{Type: "string", Enum: []interface{}{"a", "b"}}}), "arrays of unions should be grouped")
+	assert.Equal(t, "unknown", r.TypeScriptType(&schema.Property{}), "untyped columns should be unknown")
+	r.Decimal = DecimalString
+	assert.Equal(t, "string", r.TypeScriptType(&schema.Property{Type: "number", Precision: 10, Scale: 2}), "decimals represented as strings should be strings")
+}
+
+func TestMakeTypeScript(t *testing.T) {
+	r := &Request{}
+	tables := makeRelatedDbTables()
+	tables[0].Description = "The albums"
+	interfaces, err := r.MakeTypeScript(tables)
+	assert.Nil(t, err, "creating the interfaces should succeed")
+	assert.Equal(t, 2, len(interfaces), "there should be an interface per table")
+	assert.Equal(t, "/** The albums */\nexport interface Albums {\n  id: number;\n}\n", interfaces[0].Source, "the interface should be exported")
+	tracks := interfaces[1].Source
+	assert.Contains(t, tracks, "  album_id: Albums[\"id\"];\n", "foreign keys should be typed IDs")
+	assert.Contains(t, tracks, "  genre_id?: number | null;\n", "nullable columns should allow null")
+	assert.Equal(t, []string{"albums"}, interfaces[1].References, "the referenced tables should be listed")
+}
+
+func TestHandleTypeScriptOutput(t *testing.T) {
+	outdir := t.TempDir()
+	r := &Request{
+		Tables: []*schema.Table{
+			{
+				Name:   "albums",
+				Fields: []*schema.Field{{Name: "id", Type: &schema.FieldType{Name: "integer"}}},
+			},
+			{
+				Name:        "tracks",
+				Fields:      []*schema.Field{{Name: "album_id", Type: &schema.FieldType{Name: "integer"}}},
+				ForeignKeys: []*schema.ForeignKey{{Field: "album_id", ReferencedTable: "albums", ReferencedField: "id"}},
+			},
+		},
+		Format: "typescript",
+		Outdir: outdir,
+	}
+	err := r.Perform()
+	assert.Nil(t, err, "performing the request should succeed")
+	_, err = os.Stat(filepath.Join(outdir, "albums.ts"))
+	assert.Nil(t, err, "each table should be written to its own module")
+	res, err := os.ReadFile(filepath.Join(outdir, "tracks.ts"))
+	assert.Nil(t, err, "each table should be written to its own module")
+	assert.Contains(t, string(res), "import type { Albums } from \"./albums\";\n", "referenced interfaces should be imported")
+}
@@ -0,0 +1,134 @@
+package generator
+
+import (
+	"fmt"
+
+	"github.com/tgallant/db2jsonschema/internal/schema"
+)
+
+// Variants of the schema of a table. The read variant describes a full row.
+// The other variants describe request bodies: input keeps every column but
+// does not require readOnly ones, create omits readOnly columns and does not
+// require columns with a default, and update makes every column optional.
+const (
+	VariantRead   = "read"
+	VariantInput  = "input"
+	VariantCreate = "create"
+	VariantUpdate = "update"
+)
+
+var variants = []string{VariantRead, VariantInput, VariantCreate, VariantUpdate}
+
+// GetVariants returns the variants requested, defaulting to the read
+// variant.
+func (r *Request) GetVariants() ([]string, error) {
+	if len(r.Variants) == 0 {
+		return []string{VariantRead}, nil
+	}
+	for _, v := range r.Variants {
+		if !isVariant(v) {
+			return nil, fmt.Errorf("Unknown variant: %s", v)
+		}
+	}
+	return r.Variants, nil
+}
+
+func isVariant(name string) bool {
+	return hasVariant(variants, name)
+}
+
+func hasVariant(variants []string, name string) bool {
+	for _, v := range variants {
+		if v == name {
+			return true
+		}
+	}
+	return false
+}
+
+// VariantName returns the name of the schema of a table variant, which is
+// used for its title, $id and file name, e.g. albums.create.
+func VariantName(table string, variant string) string {
+	if variant == VariantRead {
+		return table
+	}
+	return fmt.Sprintf("%s.%s", table, variant)
+}
+
+func hasDefault(p *schema.Property) bool {
+	return p.Default != nil || len(p.DefaultExpression) > 0
+}
+
+// hasColumns reports whether every column of a key is a property.
+func hasColumns(props map[string]*schema.Property, columns []string) bool {
+	for _, column := range columns {
+		if _, exists := props[column]; !exists {
+			return false
+		}
+	}
+	return true
+}
+
+// MakeVariant derives the properties of a table variant from the properties
+// of its rows. Keys naming a column the variant omits are omitted too.
+func MakeVariant(t *schema.TableProperties, variant string) *schema.TableProperties {
+	if variant == VariantRead {
+		return t
+	}
+	formatted := *t
+	formatted.Name = VariantName(t.Name, variant)
+	formatted.Properties = make(map[string]*schema.Property)
+	for name, p := range t.Properties {
+		if p.ReadOnly && variant != VariantInput {
+			continue
+		}
+		formatted.Properties[name] = p
+	}
+	formatted.PrimaryKeys = nil
+	if hasColumns(formatted.Properties, t.PrimaryKeys) {
+		formatted.PrimaryKeys = t.PrimaryKeys
+	}
+	formatted.UniqueKeys = nil
+	for _, key := range t.UniqueKeys {
+		if hasColumns(formatted.Properties, key) {
+			formatted.UniqueKeys = append(formatted.UniqueKeys, key)
+		}
+	}
+	formatted.ForeignKeys = nil
+	for _, fk := range t.ForeignKeys {
+		if hasColumns(formatted.Properties, []string{fk.Field}) {
+			formatted.ForeignKeys = append(formatted.ForeignKeys, fk)
+		}
+	}
+	formatted.Required = nil
+	if variant == VariantUpdate {
+		return &formatted
+	}
+	for _, name := range t.Required {
+		p, exists := formatted.Properties[name]
+		if !exists || p.ReadOnly {
+			continue
+		}
+		if variant == VariantCreate && hasDefault(p) {
+			continue
+		}
+		formatted.Required = append(formatted.Required, name)
+	}
+	return &formatted
+}
+
+// ExpandVariants returns the properties of every requested variant of every
+// table.
+func (r *Request) ExpandVariants(tables []*schema.TableProperties) ([]*schema.TableProperties, error) {
+	variants, err := r.GetVariants()
+	if err != nil {
+		return nil, err
+	}
+	var expanded []*schema.TableProperties
+	for _, t := range tables {
+		for _, v := range variants {
+			expanded = append(expanded, MakeVariant(t, v))
+		}
+	}
+	return expanded, nil
+}
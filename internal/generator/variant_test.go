@@ -0,0 +1,131 @@
+package generator
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/tgallant/db2jsonschema/internal/schema"
+)
+
+func TestMakeVariant(t *testing.T) {
+	table := makeRelatedTables()[0]
+	table.Fields[0].AutoIncrement = true
+	table.Fields = append(table.Fields,
+		&schema.Field{Name: "title", Type: &schema.FieldType{Name: "string"}},
+		&schema.Field{Name: "released", Type: &schema.FieldType{Name: "boolean"}, Default: false},
+		&schema.Field{Name: "notes", Type: &schema.FieldType{Name: "string"}, Nullable: true},
+	)
+	props := schema.MakeTableProperties(table)
+	props.UniqueKeys = [][]string{{"title"}, {"id", "title"}}
+	props.ForeignKeys = []*schema.ForeignKey{{Field: "id", ReferencedTable: "releases", ReferencedField: "id"}}
+	read := MakeVariant(props, VariantRead)
+	assert.Equal(t, "albums", read.Name, "the read variant should keep the table name")
+	assert.Equal(t, []string{"id", "title", "released"}, read.Required, "non-nullable columns should be required")
+	input := MakeVariant(props, VariantInput)
+	assert.Equal(t, "albums.input", input.Name, "the variant name should be appended")
+	assert.Equal(t, 4, len(input.Properties), "the input variant should keep readOnly columns")
+	assert.Equal(t, []string{"title", "released"}, input.Required, "readOnly columns should not be required")
+	create := MakeVariant(props, VariantCreate)
+	assert.Equal(t, 3, len(create.Properties), "the create variant should omit readOnly columns")
+	assert.Equal(t, []string{"title"}, create.Required, "columns with a default should not be required")
+	assert.Nil(t, create.PrimaryKeys, "keys of omitted columns should be omitted")
+	assert.Equal(t, [][]string{{"title"}}, create.UniqueKeys, "keys of omitted columns should be omitted")
+	assert.Nil(t, create.ForeignKeys, "foreign keys of omitted columns should be omitted")
+	assert.Equal(t, []string{"id"}, input.PrimaryKeys, "keys of kept columns should be kept")
+	update := MakeVariant(props, VariantUpdate)
+	assert.Equal(t, 3, len(update.Properties), "the update variant should omit readOnly columns")
+	assert.Nil(t, update.Required, "no columns should be required")
+	assert.Equal(t, 4, len(props.Properties), "the table properties should not be modified")
+}
+
+func TestGetVariantsUnknown(t *testing.T) {
+	r := &Request{Variants: []string{"delete"}}
+	_, err := r.GetVariants()
+	assert.NotNil(t, err, "an unknown variant should fail")
+}
+
+func TestMakeSchemaVariants(t *testing.T) {
+	props := makeRelatedDbTables()[:1]
+	r := &Request{
+		Variants:   []string{VariantRead, VariantCreate, VariantUpdate},
+		IdTemplate: "https://example.com/{{ .Name }}.{{ .Format }}",
+	}
+	schemas, err := r.MakeSchema(props)
+	assert.Nil(t, err, "creating the schemas should succeed")
+	assert.Equal(t, 3, len(schemas), "there should be a schema per variant")
+	assert.Equal(t, "https://example.com/albums.json", schemas[0].Id, "the read variant should use the table name")
+	assert.Equal(t, "https://example.com/albums.create.json", schemas[1].Id, "each variant should have its own $id")
+	assert.Equal(t, "albums.update", schemas[2].Title, "each variant should have its own title")
+}
+
+// assertDefinedReferences asserts that every $ref of a definitions document
+// points at one of its definitions.
+func assertDefinedReferences(t *testing.T, doc *schema.DefinitionsDocument) {
+	for name, def := range doc.Defs {
+		for column, p := range def.Properties {
+			refs := []string{p.Ref}
+			for _, alternative := range p.AnyOf {
+				refs = append(refs, alternative.Ref)
+			}
+			for _, ref := range refs {
+				if len(ref) == 0 {
+					continue
+				}
+				_, exists := doc.Defs[strings.TrimPrefix(ref, "#/$defs/")]
+				assert.Truef(t, exists, "the $ref %s of %s.%s should be defined", ref, name, column)
+			}
+		}
+	}
+}
+
+func TestMakeDefinitionsDocVariantReferences(t *testing.T) {
+	r := &Request{
+		Variants:   []string{VariantCreate},
+		References: ReferencesExpand,
+	}
+	doc, err := r.MakeDefinitionsDoc(makeRelatedDbTables())
+	assert.Nil(t, err, "creating the definitions doc should succeed")
+	assertDefinedReferences(t, doc)
+	property := doc.Defs["tracks.create"].Properties["album_id"]
+	assert.Empty(t, property.Ref, "tables should not be referenced without the read variant")
+	assert.Equal(t, "number", property.Type, "the column should keep its type")
+	r.Variants = []string{VariantRead, VariantCreate}
+	doc, err = r.MakeDefinitionsDoc(makeRelatedDbTables())
+	assert.Nil(t, err, "creating the definitions doc should succeed")
+	assertDefinedReferences(t, doc)
+	property = doc.Defs["tracks.create"].Properties["album_id"]
+	assert.Equal(t, "#/$defs/albums", property.Ref, "the variants should reference the read variant")
+}
+
+func TestMakeSchemaVariantReferences(t *testing.T) {
+	r := &Request{
+		Variants:   []string{VariantCreate},
+		References: ReferencesAnnotate,
+	}
+	schemas, err := r.MakeSchema(makeRelatedDbTables())
+	assert.Nil(t, err, "creating the schemas should succeed")
+	assert.Empty(t, schemas[1].Properties["album_id"].References, "tables should not be referenced without the read variant")
+	r.Variants = []string{VariantCreate, VariantRead}
+	schemas, err = r.MakeSchema(makeRelatedDbTables())
+	assert.Nil(t, err, "creating the schemas should succeed")
+	assert.Equal(t, "albums.json#/properties/id", schemas[2].Properties["album_id"].References, "the variants should reference the $id of the read variant")
+	assert.Equal(t, "albums.json", schemas[1].Id, "the referenced schema should be generated")
+}
+
+func TestHandleDirectoryOutputVariants(t *testing.T) {
+	outdir := t.TempDir()
+	r := &Request{
+		Tables:   makeRelatedTables()[:1],
+		Outdir:   outdir,
+		Variants: []string{VariantRead, VariantCreate, VariantUpdate},
+	}
+	err := r.Perform()
+	assert.Nil(t, err, "performing the request should succeed")
+	for _, filename := range []string{"albums.json", "albums.create.json", "albums.update.json"} {
+		_, err := os.Stat(filepath.Join(outdir, filename))
+		assert.Nilf(t, err, "%s should be written", filename)
+	}
+}
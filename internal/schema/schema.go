@@ -1,68 +1,282 @@
 package schema
 
+import (
+	"encoding/json"
+	"strconv"
+	"strings"
+)
+
 type FieldType struct {
-	Name   string
-	Format string
+	Name            string
+	Format          string
+	ContentEncoding string
+	MaxLength       int
+	// FixedLength marks blank-padded types such as CHAR(n) whose values
+	// always have MaxLength characters.
+	FixedLength bool
+	// Size is the width in bits of an integer type and Unsigned its
+	// signedness. They are used to derive the range of the type.
+	Size     int
+	Unsigned bool
+	// Precision and Scale are the total and fractional number of digits
+	// of a DECIMAL(p,s) type.
+	Precision int
+	Scale     int
+	// Enum holds the values allowed by enum columns and CHECK ... IN
+	// constraints.
+	Enum        []interface{}
+	Items       *FieldType
+	UniqueItems bool
 }
 
 type Field struct {
 	Name string
 	Type *FieldType
+	// SQLType is the type of the column as declared in the database, such
+	// as varchar(255).
+	SQLType  string
+	Nullable bool
+	Comment  string
+	// Default is the JSON value of a literal column default while
+	// DefaultExpression holds defaults computed by the database, such as
+	// CURRENT_TIMESTAMP.
+	Default           interface{}
+	DefaultExpression string
+	// AutoIncrement marks columns whose value is assigned by the database
+	// on insert, such as AUTO_INCREMENT, SERIAL and rowid columns.
+	AutoIncrement bool
+	// Generated marks columns computed by the database, such as generated
+	// columns and columns set by ON UPDATE CURRENT_TIMESTAMP.
+	Generated bool
+}
+
+type ForeignKey struct {
+	Field           string
+	ReferencedTable string
+	ReferencedField string
 }
 
 type Table struct {
 	Name        string
+	Comment     string
 	Fields      []*Field
 	PrimaryKeys []string
+	ForeignKeys []*ForeignKey
+	UniqueKeys  [][]string
 }
 
-type JSONProperty struct {
-	Name   string `json:"name" yaml:"name"`
-	Type   string `json:"type" yaml:"type"`
-	Format string `json:"format,omitempty" yaml:"format,omitempty"`
+// Property is a JSON Schema describing a single column. Only keywords
+// defined by JSON Schema (plus the OpenAPI nullable keyword and x- vendor
+// extensions) are serialized.
+type Property struct {
+	Ref         string        `json:"$ref,omitempty" yaml:"$ref,omitempty"`
+	Type        interface{}   `json:"type,omitempty" yaml:"type,omitempty"`
+	Format      string        `json:"format,omitempty" yaml:"format,omitempty"`
+	Description string        `json:"description,omitempty" yaml:"description,omitempty"`
+	Default     interface{}   `json:"default,omitempty" yaml:"default,omitempty"`
+	Enum        []interface{} `json:"enum,omitempty" yaml:"enum,omitempty"`
+	MinLength   int           `json:"minLength,omitempty" yaml:"minLength,omitempty"`
+	MaxLength   int           `json:"maxLength,omitempty" yaml:"maxLength,omitempty"`
+	Pattern     string        `json:"pattern,omitempty" yaml:"pattern,omitempty"`
+	MultipleOf  interface{}   `json:"multipleOf,omitempty" yaml:"multipleOf,omitempty"`
+	// ContentEncoding is only defined by draft-07 and later.
+	ContentEncoding string      `json:"contentEncoding,omitempty" yaml:"contentEncoding,omitempty"`
+	Minimum         interface{} `json:"minimum,omitempty" yaml:"minimum,omitempty"`
+	Maximum         interface{} `json:"maximum,omitempty" yaml:"maximum,omitempty"`
+	// ExclusiveMinimum and ExclusiveMaximum are numbers in draft-06 and
+	// later but booleans in draft-04.
+	ExclusiveMinimum  interface{} `json:"exclusiveMinimum,omitempty" yaml:"exclusiveMinimum,omitempty"`
+	ExclusiveMaximum  interface{} `json:"exclusiveMaximum,omitempty" yaml:"exclusiveMaximum,omitempty"`
+	Items             *Property   `json:"items,omitempty" yaml:"items,omitempty"`
+	UniqueItems       bool        `json:"uniqueItems,omitempty" yaml:"uniqueItems,omitempty"`
+	AnyOf             []*Property `json:"anyOf,omitempty" yaml:"anyOf,omitempty"`
+	Nullable          bool        `json:"nullable,omitempty" yaml:"nullable,omitempty"`
+	References        string      `json:"x-references,omitempty" yaml:"x-references,omitempty"`
+	DefaultExpression string      `json:"x-defaultExpression,omitempty" yaml:"x-defaultExpression,omitempty"`
+	ReadOnly          bool        `json:"readOnly,omitempty" yaml:"readOnly,omitempty"`
+	// FixedLength, Precision and Scale are not serialized, the generator
+	// decides how they are represented.
+	FixedLength bool `json:"-" yaml:"-"`
+	Precision   int  `json:"-" yaml:"-"`
+	Scale       int  `json:"-" yaml:"-"`
 }
 
 type JSONSchema struct {
-	Schema     string                   `json:"$schema" yaml:"$schema"`
-	Id         string                   `json:"$id" yaml:"$id"`
-	Title      string                   `json:"title" yaml:"title"`
-	Type       string                   `json:"type" yaml:"type"`
-	Properties map[string]*JSONProperty `json:"properties" yaml:"properties"`
+	Schema      string               `json:"$schema,omitempty" yaml:"$schema,omitempty"`
+	Id          string               `json:"$id,omitempty" yaml:"$id,omitempty"`
+	LegacyId    string               `json:"id,omitempty" yaml:"id,omitempty"`
+	Title       string               `json:"title" yaml:"title"`
+	Description string               `json:"description,omitempty" yaml:"description,omitempty"`
+	Type        string               `json:"type" yaml:"type"`
+	Properties  map[string]*Property `json:"properties" yaml:"properties"`
+	Required    []string             `json:"required,omitempty" yaml:"required,omitempty"`
+	PrimaryKey  []string             `json:"x-primaryKey,omitempty" yaml:"x-primaryKey,omitempty"`
+	UniqueKeys  [][]string           `json:"x-unique,omitempty" yaml:"x-unique,omitempty"`
+}
+
+type JSONDefinition struct {
+	Description string               `json:"description,omitempty" yaml:"description,omitempty"`
+	Type        string               `json:"type" yaml:"type"`
+	Properties  map[string]*Property `json:"properties" yaml:"properties"`
+	Required    []string             `json:"required,omitempty" yaml:"required,omitempty"`
+	PrimaryKey  []string             `json:"x-primaryKey,omitempty" yaml:"x-primaryKey,omitempty"`
+	UniqueKeys  [][]string           `json:"x-unique,omitempty" yaml:"x-unique,omitempty"`
 }
 
 type DefinitionsDocument struct {
-	Schema      string                              `json:"$schema" yaml:"$schema"`
-	Id          string                              `json:"$id" yaml:"$id"`
-	Title       string                              `json:"title" yaml:"title"`
-	Definitions map[string]map[string]*JSONProperty `json:"definitions" yaml:"definitions"`
+	Schema      string                     `json:"$schema,omitempty" yaml:"$schema,omitempty"`
+	Id          string                     `json:"$id,omitempty" yaml:"$id,omitempty"`
+	LegacyId    string                     `json:"id,omitempty" yaml:"id,omitempty"`
+	Title       string                     `json:"title" yaml:"title"`
+	Defs        map[string]*JSONDefinition `json:"$defs,omitempty" yaml:"$defs,omitempty"`
+	Definitions map[string]*JSONDefinition `json:"definitions,omitempty" yaml:"definitions,omitempty"`
+}
+
+type OpenAPIInfo struct {
+	Title   string `json:"title" yaml:"title"`
+	Version string `json:"version" yaml:"version"`
+}
+
+type OpenAPIComponents struct {
+	Schemas map[string]*JSONDefinition `json:"schemas" yaml:"schemas"`
+}
+
+type OpenAPIDocument struct {
+	OpenAPI    string                 `json:"openapi" yaml:"openapi"`
+	Info       *OpenAPIInfo           `json:"info" yaml:"info"`
+	Paths      map[string]interface{} `json:"paths" yaml:"paths"`
+	Components *OpenAPIComponents     `json:"components" yaml:"components"`
 }
 
 type TableProperties struct {
-	Name       string
-	Properties []*JSONProperty
+	Name        string
+	Description string
+	Properties  map[string]*Property
+	Required    []string
+	PrimaryKeys []string
+	ForeignKeys []*ForeignKey
+	UniqueKeys  [][]string
+}
+
+// IntegerBounds returns the minimum and maximum values of an integer type
+// with the given width in bits.
+func IntegerBounds(size int, unsigned bool) (interface{}, interface{}) {
+	if unsigned {
+		return 0, ^uint64(0) >> (64 - size)
+	}
+	minimum := int64(-1) << (size - 1)
+	return minimum, ^minimum
+}
+
+// IntegerFormat returns the OpenAPI integer format able to hold every value
+// of an integer type, if any.
+func IntegerFormat(size int, unsigned bool) string {
+	if unsigned {
+		size++
+	}
+	switch {
+	case size <= 32:
+		return "int32"
+	case size <= 64:
+		return "int64"
+	default:
+		return ""
+	}
+}
+
+// ConvertLiteral converts the text of a SQL literal into a JSON value of the
+// given type. It reports false when the literal is not a value of the type.
+func ConvertLiteral(t *FieldType, literal string) (interface{}, bool) {
+	switch t.Name {
+	case "boolean":
+		switch strings.ToLower(literal) {
+		case "1", "true", "t", "yes", "y", "on":
+			return true, true
+		case "0", "false", "f", "no", "n", "off":
+			return false, true
+		}
+		return nil, false
+	case "integer":
+		value, err := strconv.ParseInt(literal, 10, 64)
+		if err != nil {
+			return nil, false
+		}
+		return value, true
+	case "number":
+		if value, err := strconv.ParseInt(literal, 10, 64); err == nil {
+			return value, true
+		}
+		value, err := strconv.ParseFloat(literal, 64)
+		if err != nil {
+			return nil, false
+		}
+		return value, true
+	case "array", "object":
+		return nil, false
+	case "":
+		// Columns of any type, such as JSON columns, hold JSON documents.
+		var value interface{}
+		if err := json.Unmarshal([]byte(literal), &value); err == nil {
+			return value, true
+		}
+		return literal, true
+	default:
+		return literal, true
+	}
+}
+
+func MakeProperty(t *FieldType) *Property {
+	prop := &Property{
+		Format:          t.Format,
+		ContentEncoding: t.ContentEncoding,
+		MaxLength:       t.MaxLength,
+		FixedLength:     t.FixedLength,
+		Precision:       t.Precision,
+		Scale:           t.Scale,
+		Enum:            t.Enum,
+		UniqueItems:     t.UniqueItems,
+	}
+	if len(t.Name) > 0 {
+		prop.Type = t.Name
+	}
+	if t.Name == "integer" && t.Size > 0 {
+		prop.Minimum, prop.Maximum = IntegerBounds(t.Size, t.Unsigned)
+		if len(prop.Format) == 0 {
+			prop.Format = IntegerFormat(t.Size, t.Unsigned)
+		}
+	}
+	if t.Name == "number" && t.Unsigned {
+		prop.Minimum = 0
+	}
+	if t.Items != nil {
+		prop.Items = MakeProperty(t.Items)
+	}
+	return prop
 }
 
 func MakeTableProperties(t *Table) *TableProperties {
-	var properties []*JSONProperty
+	var properties = make(map[string]*Property)
+	var required []string
 	for _, field := range t.Fields {
-		prop := &JSONProperty{
-			Name:   field.Name,
-			Type:   field.Type.Name,
-			Format: field.Type.Format,
+		prop := MakeProperty(field.Type)
+		prop.Nullable = field.Nullable
+		prop.Description = field.Comment
+		prop.Default = field.Default
+		prop.DefaultExpression = field.DefaultExpression
+		prop.ReadOnly = field.AutoIncrement || field.Generated
+		properties[field.Name] = prop
+		if !field.Nullable {
+			required = append(required, field.Name)
 		}
-		properties = append(properties, prop)
 	}
 	tableProperties := &TableProperties{
-		Name:       t.Name,
-		Properties: properties,
+		Name:        t.Name,
+		Description: t.Comment,
+		Properties:  properties,
+		Required:    required,
+		PrimaryKeys: t.PrimaryKeys,
+		ForeignKeys: t.ForeignKeys,
+		UniqueKeys:  t.UniqueKeys,
 	}
 	return tableProperties
 }
-
-func MakePropertiesMap(props []*JSONProperty) map[string]*JSONProperty {
-	var propsMap = make(map[string]*JSONProperty)
-	for _, p := range props {
-		propsMap[p.Name] = p
-	}
-	return propsMap
-}
@@ -1,8 +1,11 @@
 package schema
 
 import (
-	"github.com/stretchr/testify/assert"
+	"encoding/json"
+	"math"
 	"testing"
+
+	"github.com/stretchr/testify/assert"
 )
 
 func makeDbTable() *Table {
@@ -34,17 +37,106 @@ func TestMakeTableProperties(t *testing.T) {
 	p := MakeTableProperties(table)
 	assert.Equal(t, "Testing", p.Name, "name should be `Testing`")
 	assert.Equal(t, 2, len(p.Properties), "should have 2 properties")
+	assert.Equal(t, 2, len(p.Required), "should have 2 required properties")
+}
+
+func TestMakeTablePropertiesNullable(t *testing.T) {
+	table := makeDbTable()
+	table.Fields[0].Nullable = true
+	p := MakeTableProperties(table)
+	assert.Equal(t, []string{"UserId"}, p.Required, "only `UserId` should be required")
+	assert.True(t, p.Properties["exampleField"].Nullable, "`exampleField` should be nullable")
 }
 
-func TestMakePropertiesMap(t *testing.T) {
-	var props []*JSONProperty
-	prop := &JSONProperty{
-		Name: "email",
-		Type: "string",
+func TestMakeProperty(t *testing.T) {
+	fieldType := &FieldType{
+		Name: "array",
+		Items: &FieldType{
+			Name:   "string",
+			Format: "date",
+		},
 	}
-	props = append(props, prop)
-	m := MakePropertiesMap(props)
-	assert.Equal(t, 1, len(m), "should have 1 property")
-	p := m["email"]
-	assert.Equal(t, "string", p.Type, "type should be `string`")
+	p := MakeProperty(fieldType)
+	assert.Equal(t, "array", p.Type, "type should be `array`")
+	assert.Equal(t, "string", p.Items.Type, "item type should be `string`")
+	assert.Equal(t, "date", p.Items.Format, "item format should be `date`")
+}
+
+func TestMakePropertyWithoutType(t *testing.T) {
+	p := MakeProperty(&FieldType{})
+	assert.Nil(t, p.Type, "type should not be set")
+	res, err := json.Marshal(p)
+	assert.Nil(t, err, "marshalling the property should succeed")
+	assert.Equal(t, "{}", string(res), "the property should accept any value")
+}
+
+func TestIntegerBounds(t *testing.T) {
+	minimum, maximum := IntegerBounds(8, false)
+	assert.Equal(t, int64(-128), minimum, "the minimum should be -128")
+	assert.Equal(t, int64(127), maximum, "the maximum should be 127")
+	minimum, maximum = IntegerBounds(64, false)
+	assert.Equal(t, int64(math.MinInt64), minimum, "the minimum should be the int64 minimum")
+	assert.Equal(t, int64(math.MaxInt64), maximum, "the maximum should be the int64 maximum")
+	minimum, maximum = IntegerBounds(64, true)
+	assert.Equal(t, 0, minimum, "the minimum should be 0")
+	assert.Equal(t, uint64(math.MaxUint64), maximum, "the maximum should be the uint64 maximum")
+}
+
+func TestMakePropertyInteger(t *testing.T) {
+	p := MakeProperty(&FieldType{Name: "integer", Size: 32, Unsigned: true})
+	assert.Equal(t, "integer", p.Type, "type should be `integer`")
+	assert.Equal(t, "int64", p.Format, "format should be `int64`")
+	assert.Equal(t, 0, p.Minimum, "the minimum should be 0")
+	assert.Equal(t, uint64(math.MaxUint32), p.Maximum, "the maximum should be the uint32 maximum")
+	p = MakeProperty(&FieldType{Name: "integer", Size: 16})
+	assert.Equal(t, "int32", p.Format, "format should be `int32`")
+	p = MakeProperty(&FieldType{Name: "integer", Size: 64, Unsigned: true})
+	assert.Empty(t, p.Format, "no format can hold an unsigned 64 bit integer")
+	res, err := json.Marshal(p)
+	assert.Nil(t, err, "marshalling the property should succeed")
+	assert.Equal(t, `{"type":"integer","minimum":0,"maximum":18446744073709551615}`, string(res), "the bounds should be exact")
+}
+
+func TestConvertLiteral(t *testing.T) {
+	cases := []struct {
+		name    string
+		literal string
+		value   interface{}
+		ok      bool
+	}{
+		{"boolean", "1", true, true},
+		{"boolean", "false", false, true},
+		{"boolean", "maybe", nil, false},
+		{"integer", "-7", int64(-7), true},
+		{"integer", "1.5", nil, false},
+		{"number", "1.5", 1.5, true},
+		{"number", "10", int64(10), true},
+		{"string", "10", "10", true},
+		{"", `{"a": 1}`, map[string]interface{}{"a": 1.0}, true},
+		{"array", "{}", nil, false},
+	}
+	for _, c := range cases {
+		value, ok := ConvertLiteral(&FieldType{Name: c.name}, c.literal)
+		assert.Equalf(t, c.ok, ok, "converting %s to %s should report whether it succeeded", c.literal, c.name)
+		assert.Equalf(t, c.value, value, "converting %s to %s should produce a JSON value", c.literal, c.name)
+	}
+}
+
+func TestMakeTablePropertiesDefault(t *testing.T) {
+	table := makeDbTable()
+	table.Fields[0].Default = "n/a"
+	table.Fields[1].DefaultExpression = "CURRENT_TIMESTAMP"
+	p := MakeTableProperties(table)
+	assert.Equal(t, "n/a", p.Properties["exampleField"].Default, "the default should be set")
+	assert.Nil(t, p.Properties["UserId"].Default, "expressions should not be used as default")
+	assert.Equal(t, "CURRENT_TIMESTAMP", p.Properties["UserId"].DefaultExpression, "the expression should be kept")
+}
+
+func TestMakeTablePropertiesReadOnly(t *testing.T) {
+	table := makeDbTable()
+	table.Fields[0].Generated = true
+	table.Fields[1].AutoIncrement = true
+	p := MakeTableProperties(table)
+	assert.True(t, p.Properties["exampleField"].ReadOnly, "generated columns should be readOnly")
+	assert.True(t, p.Properties["UserId"].ReadOnly, "auto-increment columns should be readOnly")
 }